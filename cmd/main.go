@@ -19,8 +19,20 @@ var (
 	configPath string
 	model      string
 	stream     bool
+	worktree   string
+	planMode   bool
+
+	printMode       bool
+	outputFormat    string
+	permissionMode  string
+	allowedTools    []string
+	disallowedTools []string
+	verbose         bool
 )
 
+// worktreeAutoName is the --worktree value used when no name is given
+const worktreeAutoName = "auto"
+
 func main() {
 	var rootCmd = &cobra.Command{
 		Use:   "agent_go [message]",
@@ -28,12 +40,25 @@ func main() {
 		Long: `Agent_Go is a powerful AI coding assistant that can execute tasks using various tools.
 It integrates with OpenRouter to provide access to multiple LLM models and includes
 features like context management, file operations, and more.`,
-		Run: runAgent,
+		Args: cobra.ArbitraryArgs,
+		Run:  runAgent,
 	}
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(newServeCommand())
+	rootCmd.AddCommand(newMCPServeCommand())
 
 	rootCmd.Flags().StringVarP(&configPath, "config", "c", "", "Path to config file")
 	rootCmd.Flags().StringVarP(&model, "model", "m", "", "Override model from config")
 	rootCmd.Flags().BoolVarP(&stream, "stream", "s", true, "Enable streaming responses")
+	rootCmd.Flags().StringVarP(&worktree, "worktree", "w", "", "Work in an isolated git worktree on a new branch (optionally --worktree=<name>)")
+	rootCmd.Flags().Lookup("worktree").NoOptDefVal = worktreeAutoName
+	rootCmd.Flags().BoolVar(&planMode, "plan", false, "Start in plan mode: investigate read-only and approve a plan before changes")
+	rootCmd.Flags().BoolVarP(&printMode, "print", "p", false, "Answer the prompt (from arguments or stdin) without interaction and print the result")
+	rootCmd.Flags().StringVar(&outputFormat, "output-format", outputText, "With --print: text, json or stream-json (one JSON event per line)")
+	rootCmd.Flags().StringVar(&permissionMode, "permission-mode", agent.PermissionEdit, "With --print: tools allowed to run: read-only, edit or all")
+	rootCmd.Flags().StringSliceVar(&allowedTools, "allowed-tools", nil, "With --print: tools to allow regardless of the permission mode (e.g. run_command)")
+	rootCmd.Flags().StringSliceVar(&disallowedTools, "disallowed-tools", nil, "With --print: tools to refuse regardless of the permission mode")
+	rootCmd.Flags().BoolVar(&verbose, "verbose", false, "With --print: show progress on stderr")
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -42,6 +67,10 @@ features like context management, file operations, and more.`,
 }
 
 func runAgent(cmd *cobra.Command, args []string) {
+	if printMode {
+		os.Exit(runPrint(args))
+	}
+
 	// Load config
 	cfg, err := config.Load()
 	if err != nil {
@@ -57,14 +86,37 @@ func runAgent(cmd *cobra.Command, args []string) {
 
 	// create agent
 	aiAgent := agent.NewAgent(cfg)
+	defer aiAgent.Close()
 
 	// print welcome message
 	printWelcome(cfg)
 
+	// isolate the session in a worktree if requested
+	if worktree != "" {
+		task := worktree
+		if task == worktreeAutoName {
+			task = strings.Join(args, " ")
+		}
+		result, err := aiAgent.StartWorktree(task)
+		if err != nil {
+			color.New(color.FgRed).Printf("Worktree error: %v\n", err)
+			return
+		}
+		color.New(color.FgHiBlack).Println(result)
+		fmt.Println()
+	}
+
+	if planMode {
+		color.New(color.FgHiBlack).Println(aiAgent.SetPlanMode(true))
+		fmt.Println()
+	}
+
 	// handle direct command
 	if len(args) > 0 {
 		message := strings.Join(args, " ")
 		handleMessage(aiAgent, message)
+		offerPlanReview(aiAgent)
+		offerWorktreeFinish(aiAgent)
 		return
 	}
 
@@ -102,6 +154,7 @@ func runInteractiveMode(aiAgent *agent.Agent) {
 		// Handle built-in commands first
 		switch input {
 		case "exit", "quit", "q":
+			offerWorktreeFinish(aiAgent)
 			color.New(color.FgHiBlack).Println("\nGoodbye!")
 			return
 		}
@@ -118,6 +171,7 @@ func runInteractiveMode(aiAgent *agent.Agent) {
 			} else {
 				// Handle exit command
 				if result == "EXIT_APPLICATION" {
+					offerWorktreeFinish(aiAgent)
 					color.New(color.FgHiBlack).Println("\nGoodbye!")
 					return
 				}
@@ -128,11 +182,70 @@ func runInteractiveMode(aiAgent *agent.Agent) {
 
 		// Handle as regular message
 		handleMessage(aiAgent, input)
+		offerPlanReview(aiAgent)
 	}
 
 	if err := scanner.Err(); err != nil {
 		color.Red("Error reading input: %v", err)
 	}
+	offerWorktreeFinish(aiAgent)
+}
+
+// offerWorktreeFinish asks whether to merge, discard or keep an active worktree
+func offerWorktreeFinish(aiAgent *agent.Agent) {
+	if !aiAgent.InWorktree() {
+		return
+	}
+
+	fmt.Println()
+	color.New(color.FgHiBlack).Println(aiAgent.WorktreeStatus())
+	prompt := ui.NewCommandPrompt()
+	choice := prompt.AskChoice("Task finished. What should happen to the worktree?", []string{"merge", "discard", "keep"}, "keep")
+
+	result, err := aiAgent.FinishWorktree(choice)
+	if err != nil {
+		color.Red("Worktree error: %v", err)
+		return
+	}
+	color.Green("%s", result)
+}
+
+// offerPlanReview asks whether to approve, edit or reject a plan proposed in
+// plan mode, and starts executing it once approved
+func offerPlanReview(aiAgent *agent.Agent) {
+	plan := aiAgent.ProposedPlan()
+	if !aiAgent.InPlanMode() || plan == "" {
+		return
+	}
+
+	fmt.Println()
+	prompt := ui.NewCommandPrompt()
+	choice := prompt.AskChoice("Review the proposed plan", []string{"approve", "edit", "reject", "later"}, "later")
+
+	switch choice {
+	case "approve":
+	case "edit":
+		edited, err := commands.EditPlan(plan)
+		if err != nil {
+			color.Red("Plan error: %v", err)
+			return
+		}
+		plan = edited
+	case "reject":
+		color.New(color.FgHiBlack).Println(aiAgent.RejectPlan())
+		return
+	default:
+		color.New(color.FgHiBlack).Println("Plan kept for review; use /plan approve, /plan edit or /plan reject")
+		return
+	}
+
+	result, err := aiAgent.ApprovePlan(plan)
+	if err != nil {
+		color.Red("Plan error: %v", err)
+		return
+	}
+	color.Green("%s", result)
+	handleMessage(aiAgent, agent.PlanExecutionPrompt)
 }
 
 func determineActivityType(message string) string {
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ttli3/go-coding-agent/internal/agent"
+	"github.com/ttli3/go-coding-agent/internal/config"
+	"github.com/ttli3/go-coding-agent/internal/mcp"
+	"github.com/ttli3/go-coding-agent/internal/project"
+	"github.com/ttli3/go-coding-agent/internal/tools"
+	"github.com/ttli3/go-coding-agent/internal/ui"
+)
+
+var mcpRoot string
+
+func newMCPServeCommand() *cobra.Command {
+	mcpCmd := &cobra.Command{
+		Use:   "mcp-serve",
+		Short: "Offer the built-in tools to other agents as an MCP server over stdio",
+		Long: `Offer the built-in tools (read_file, grep_search, edit_file, ...) to other
+agents as a Model Context Protocol server over stdio. Tools are limited by the
+permission mode, and their path arguments must stay inside the workspace.`,
+		Args:          cobra.NoArgs,
+		RunE:          runMCPServe,
+		SilenceUsage:  true,
+		SilenceErrors: true, // printed by main
+	}
+	mcpCmd.Flags().StringVar(&mcpRoot, "root", "", "Workspace the tools are confined to (default: the current directory)")
+	mcpCmd.Flags().StringVar(&permissionMode, "permission-mode", agent.PermissionEdit, "Tools offered: read-only, edit or all")
+	mcpCmd.Flags().StringSliceVar(&allowedTools, "allowed-tools", nil, "Tools to offer regardless of the permission mode (e.g. run_command)")
+	mcpCmd.Flags().StringSliceVar(&disallowedTools, "disallowed-tools", nil, "Tools to withhold regardless of the permission mode")
+	return mcpCmd
+}
+
+func runMCPServe(cmd *cobra.Command, args []string) error {
+	policy := &agent.ToolPolicy{Mode: permissionMode, Allowed: allowedTools, Disallowed: disallowedTools}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	root := mcpRoot
+	if root == "" {
+		root = "."
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	// Relative paths in tool arguments are resolved against the workspace
+	if err := os.Chdir(root); err != nil {
+		return fmt.Errorf("invalid workspace: %w", err)
+	}
+
+	// stdout carries the protocol, so anything printed for people, such as
+	// plugin warnings and tool output, goes to stderr
+	out := redirectOutput(true)
+	ui.SetNonInteractive(true)
+
+	cfg, err := config.LoadSettings()
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	tools.SetPluginTrust(tools.PluginTrust{TrustedDirs: cfg.Plugins.TrustedDirs, ReadOnly: cfg.Plugins.ReadOnly})
+	registry := tools.GetDefaultRegistry()
+	layout := project.Detect(root)
+	if layout.HasKind("go") {
+		tools.RegisterGoTools(registry)
+	}
+	registry.Register(tools.NewRunTestsTool(staticLayout{layout}))
+	if err := registry.SetRoot(root); err != nil {
+		return err
+	}
+
+	return mcp.NewServer(registry, policy.Permits).ServeStdio(os.Stdin, out)
+}
+
+// staticLayout provides the layout detected at startup to run_tests
+type staticLayout struct {
+	layout *project.Layout
+}
+
+func (s staticLayout) ProjectLayout() *project.Layout {
+	return s.layout
+}
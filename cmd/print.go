@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/ttli3/go-coding-agent/internal/agent"
+	"github.com/ttli3/go-coding-agent/internal/config"
+	"github.com/ttli3/go-coding-agent/internal/openrouter"
+	"github.com/ttli3/go-coding-agent/internal/ui"
+)
+
+// Output formats for --print
+const (
+	outputText       = "text"        // the final response only
+	outputJSON       = "json"        // one result object
+	outputStreamJSON = "stream-json" // one event per line, then the result
+)
+
+// Exit codes for --print
+const (
+	exitOK           = 0
+	exitError        = 1 // configuration, API or agent error
+	exitUsage        = 2 // invalid flags or no prompt
+	exitVerifyFailed = 3 // files were changed and verification still failed
+)
+
+// printResult is the final JSON object of --print
+type printResult struct {
+	Type       string           `json:"type"`
+	Result     string           `json:"result"`
+	IsError    bool             `json:"is_error"`
+	Error      string           `json:"error,omitempty"`
+	ExitCode   int              `json:"exit_code"`
+	Model      string           `json:"model"`
+	DurationMS int64            `json:"duration_ms"`
+	ToolCalls  int              `json:"tool_calls"`
+	Usage      openrouter.Usage `json:"usage"`
+	Worktree   string           `json:"worktree,omitempty"` // with --worktree, where the changes were committed
+	Branch     string           `json:"branch,omitempty"`
+}
+
+// runPrint answers one prompt without any interaction and returns the exit
+// code. Only the result is written to stdout; progress goes to stderr with
+// --verbose and is discarded otherwise.
+func runPrint(args []string) int {
+	switch outputFormat {
+	case outputText, outputJSON, outputStreamJSON:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q (use %s, %s or %s)\n", outputFormat, outputText, outputJSON, outputStreamJSON)
+		return exitUsage
+	}
+	policy := &agent.ToolPolicy{Mode: permissionMode, Allowed: allowedTools, Disallowed: disallowedTools}
+	if err := policy.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	prompt, err := readPrompt(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	out := redirectOutput(verbose)
+	start := time.Now()
+	result := printResult{Type: "result"}
+	finish := func(code int, err error) int {
+		result.ExitCode = code
+		result.DurationMS = time.Since(start).Milliseconds()
+		if err != nil {
+			result.IsError = true
+			result.Error = err.Error()
+		}
+		writeResult(out, result)
+		return code
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return finish(exitError, fmt.Errorf("configuration error: %w", err))
+	}
+	if model != "" {
+		cfg.OpenRouter.Model = model
+	}
+	result.Model = cfg.OpenRouter.Model
+
+	ui.SetNonInteractive(true)
+	aiAgent := agent.NewAgent(cfg)
+	defer aiAgent.Close()
+	aiAgent.SetToolPolicy(policy)
+
+	encoder := json.NewEncoder(out)
+	aiAgent.SetEventHandler(func(event agent.Event) {
+		if event.Type == agent.EventToolCall {
+			result.ToolCalls++
+		}
+		if outputFormat == outputStreamJSON {
+			encoder.Encode(event)
+		}
+	})
+
+	if worktree != "" {
+		task := worktree
+		if task == worktreeAutoName {
+			task = prompt
+		}
+		if _, err := aiAgent.StartWorktree(task); err != nil {
+			return finish(exitError, fmt.Errorf("worktree error: %w", err))
+		}
+	}
+	if planMode {
+		aiAgent.SetPlanMode(true)
+	}
+
+	response, err := aiAgent.ProcessMessage(prompt)
+	result.Result = response
+	if aiAgent.InWorktree() {
+		// Nothing can merge the worktree once this process exits, so its
+		// changes are committed on its branch and reported
+		info, saveErr := aiAgent.SaveWorktree()
+		result.Worktree, result.Branch = info.Path, info.Branch
+		if saveErr != nil && err == nil {
+			err = fmt.Errorf("failed to commit the worktree changes: %w", saveErr)
+		}
+	}
+	result.Usage = aiAgent.Usage()
+	if err != nil {
+		return finish(exitError, err)
+	}
+	if aiAgent.VerificationFailed() {
+		return finish(exitVerifyFailed, fmt.Errorf("verification failed"))
+	}
+	return finish(exitOK, nil)
+}
+
+// readPrompt joins the arguments and anything piped on stdin
+func readPrompt(args []string) (string, error) {
+	prompt := strings.TrimSpace(strings.Join(args, " "))
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %w", err)
+		}
+		if piped := strings.TrimSpace(string(data)); piped != "" {
+			if prompt == "" {
+				prompt = piped
+			} else {
+				prompt += "\n\n" + piped
+			}
+		}
+	}
+	if prompt == "" {
+		return "", fmt.Errorf("no prompt: pass it as an argument or on stdin")
+	}
+	return prompt, nil
+}
+
+// redirectOutput points everything printed for people at stderr (verbose) or
+// nowhere, without colors, and returns the real stdout for the result
+func redirectOutput(verbose bool) *os.File {
+	out := os.Stdout
+	sink := os.Stderr
+	if !verbose {
+		if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+			sink = devNull
+		}
+	}
+	os.Stdout = sink
+	color.Output = sink
+	color.NoColor = true
+	return out
+}
+
+// writeResult writes the result in the chosen format; in text mode errors go
+// to stderr
+func writeResult(out *os.File, result printResult) {
+	if outputFormat != outputText {
+		json.NewEncoder(out).Encode(result)
+		return
+	}
+	if result.Result != "" {
+		fmt.Fprintln(out, result.Result)
+	}
+	if result.Worktree != "" {
+		fmt.Fprintf(os.Stderr, "Changes are on branch %s in worktree %s\n", result.Branch, result.Worktree)
+	}
+	if result.Error != "" {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", result.Error)
+	}
+}
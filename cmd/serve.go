@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ttli3/go-coding-agent/internal/agent"
+	"github.com/ttli3/go-coding-agent/internal/config"
+	"github.com/ttli3/go-coding-agent/internal/server"
+	"github.com/ttli3/go-coding-agent/internal/ui"
+)
+
+// tokenEnv names the environment variable read when --token isn't given
+const tokenEnv = "AGENT_GO_TOKEN"
+
+var (
+	serveAddr   string
+	serveSocket string
+	serveToken  string
+)
+
+func newServeCommand() *cobra.Command {
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve agent sessions over a local HTTP API",
+		Long: `Serve agent sessions over a local HTTP API with server-sent events, for web
+UIs and editor plugins. Every request needs the token, as a bearer token or
+the token query parameter. Tool calls outside a session's permission mode
+wait for approval through the API.`,
+		Args:          cobra.NoArgs,
+		RunE:          runServe,
+		SilenceUsage:  true,
+		SilenceErrors: true, // printed by main
+	}
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:7433", "Local address to listen on")
+	serveCmd.Flags().StringVar(&serveSocket, "socket", "", "Listen on this Unix socket instead of --addr")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "Token clients must send (default: $"+tokenEnv+", or a random one that is printed)")
+	serveCmd.Flags().StringVarP(&model, "model", "m", "", "Override model from config")
+	serveCmd.Flags().StringVar(&permissionMode, "permission-mode", agent.PermissionEdit, "Default for new sessions: tools that run without approval: read-only, edit or all")
+	serveCmd.Flags().BoolVar(&verbose, "verbose", false, "Show the sessions' progress on stderr")
+	return serveCmd
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	policy := &agent.ToolPolicy{Mode: permissionMode}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	if model != "" {
+		cfg.OpenRouter.Model = model
+	}
+
+	token := serveToken
+	if token == "" {
+		token = os.Getenv(tokenEnv)
+	}
+	generated := token == ""
+	if generated {
+		if token, err = server.GenerateToken(); err != nil {
+			return err
+		}
+	}
+
+	listener, url, err := listen()
+	if err != nil {
+		return err
+	}
+
+	// Progress printed for people goes to stderr with --verbose and is
+	// discarded otherwise; nothing may wait on stdin
+	out := redirectOutput(verbose)
+	ui.SetNonInteractive(true)
+
+	fmt.Fprintf(out, "Serving on %s\n", url)
+	if generated {
+		fmt.Fprintf(out, "Token: %s\n", token)
+	}
+
+	srv := &http.Server{
+		Handler:           server.New(cfg, server.Options{Token: token, PermissionMode: permissionMode}).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	stop, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	go func() {
+		<-stop.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// listen opens the Unix socket, readable only by the user, or the loopback
+// TCP address, and returns a description of it
+func listen() (net.Listener, string, error) {
+	if serveSocket == "" {
+		if err := server.CheckLoopback(serveAddr); err != nil {
+			return nil, "", err
+		}
+		listener, err := net.Listen("tcp", serveAddr)
+		if err != nil {
+			return nil, "", err
+		}
+		return listener, "http://" + listener.Addr().String(), nil
+	}
+
+	// A socket left behind by a server that didn't shut down cleanly
+	if info, err := os.Stat(serveSocket); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", serveSocket); err == nil {
+			conn.Close()
+			return nil, "", fmt.Errorf("%s is in use by another server", serveSocket)
+		}
+		os.Remove(serveSocket)
+	}
+	listener, err := net.Listen("unix", serveSocket)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := os.Chmod(serveSocket, 0600); err != nil {
+		listener.Close()
+		return nil, "", err
+	}
+	return listener, "unix:" + serveSocket, nil
+}
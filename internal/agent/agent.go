@@ -7,10 +7,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/ttli3/go-coding-agent/internal/config"
 	"github.com/ttli3/go-coding-agent/internal/context"
+	"github.com/ttli3/go-coding-agent/internal/hooks"
 	"github.com/ttli3/go-coding-agent/internal/openrouter"
+	"github.com/ttli3/go-coding-agent/internal/repomap"
 	"github.com/ttli3/go-coding-agent/internal/tools"
 	"github.com/ttli3/go-coding-agent/internal/ui"
 )
@@ -21,9 +24,39 @@ type Agent struct {
 	Config         *config.Config
 	sessionContext *context.SessionContext
 	contextWindow  *context.ContextWindow
+	gitState       gitCommitState
+	worktree       *worktreeState
+	repoMap        *repomap.Map
+	repoMapQuery   string
+	instructions   instructionState
+	plan           planState
+	verify         verifyState
+	policy         *ToolPolicy // tools allowed without confirmation; nil allows all
+	approver       Approver    // asked about calls outside the policy; nil refuses them
+	events         func(Event)
+	usage          openrouter.Usage
+	usageMu        sync.Mutex // guards usage, which delegate calls running in parallel add to
+	sessionFile    string // where the session is saved; empty for sessions that aren't kept
+
+	hooks              *hooks.Runner
+	sessionHookContext string // output of session_start hooks, added to the system prompt
+
+	mcpServers []*mcpServer
 }
 
+// NewAgent creates an agent that resumes the saved session and saves it
+// after every message
 func NewAgent(cfg *config.Config) *Agent {
+	return newAgent(cfg, defaultSessionFile())
+}
+
+// NewEphemeralAgent creates an agent with a fresh session that isn't saved,
+// for callers such as the HTTP API that run several sessions at once
+func NewEphemeralAgent(cfg *config.Config) *Agent {
+	return newAgent(cfg, "")
+}
+
+func newAgent(cfg *config.Config, sessionFile string) *Agent {
 	client := openrouter.NewClient(
 		cfg.OpenRouter.APIKey,
 		cfg.OpenRouter.BaseURL,
@@ -34,24 +67,46 @@ func NewAgent(cfg *config.Config) *Agent {
 	sessionCtx.DetectProjectType()
 	
 	contextWindow := context.NewContextWindow(getModelContextLimit(cfg.OpenRouter.Model))
+	contextWindow.SetCompactionPolicy(context.NewCompactionPolicy(cfg.Agent.CompactAfterTurns))
+	contextWindow.PinnedTokenBudget = cfg.Agent.PinnedTokens
 
+	tools.SetPluginTrust(tools.PluginTrust{TrustedDirs: cfg.Plugins.TrustedDirs, ReadOnly: cfg.Plugins.ReadOnly})
 	agent := &Agent{
 		client:         client,
 		toolRegistry:   tools.GetDefaultRegistry(),
 		Config:         cfg,
 		sessionContext: sessionCtx,
 		contextWindow:  contextWindow,
+		gitState: gitCommitState{
+			autoCommit: cfg.Git.AutoCommit,
+			branch:     cfg.Git.CommitBranch,
+		},
+		verify:      verifyState{enabled: cfg.Verify.Enabled},
+		sessionFile: sessionFile,
 	}
 	
 	// Automatically load previous session if it exists
 	agent.LoadSession()
+
+	// Symbol navigation is only available for Go projects, including Go
+	// sub-projects of a monorepo
+	if layout := agent.sessionContext.Layout; agent.sessionContext.ProjectType == "go" || (layout != nil && layout.HasKind("go")) {
+		tools.RegisterGoTools(agent.toolRegistry)
+	}
+
+	agent.toolRegistry.Register(tools.NewRunTestsTool(agent))
+	agent.registerTodoTools()
+	agent.registerDelegateTool()
+	agent.loadMCPServers()
+	agent.indexProject()
+	agent.loadInstructions()
+	agent.loadHooks()
 	
 	return agent
 }
 
 func (a *Agent) AddMessage(role, content string) {
-	important := isImportantMessage(role, content)
-	a.contextWindow.AddMessage(role, content, important)
+	a.contextWindow.AddMessage(role, content, a.shouldAutoPin(role, content))
 }
 
 func (a *Agent) GetSystemPrompt() string {
@@ -77,9 +132,23 @@ You have access to these functions:
 - search_code: Search for patterns in files
 - replace_content: Replace text patterns in files
 - grep_search: Search across multiple files
+- search_codebase: Ranked search of the whole project for words or identifiers when you don't know where something lives
 - run_command: Execute system commands
+- run_tests: Run the project's tests and get pass/fail counts with each failure's output and file:line
 - get_working_directory: Get current directory
 - show_diff: Show differences between file versions
+- git_status: Show branch and changed files (read-only, no confirmation needed)
+- git_diff: Show unstaged, staged or ref-based diffs
+- git_log: Show recent commits
+- git_blame: Show who last changed each line of a file
+- git_show: Show a commit, or a file as of a commit
+- todo_write: Create or update your checklist for multi-step work
+- todo_read: Show your checklist
+- delegate: Hand self-contained investigations to sub-agents that run concurrently and return only their reports
+
+Prefer the git_* functions over run_command for inspecting repository state, and run_tests over run_command for running tests.
+Use delegate for broad exploration (e.g. surveying several packages) so the search results stay out of this conversation.
+For tasks with three or more steps, write a checklist with todo_write first and keep it updated as you finish each item.
 
 WORKFLOW:
 1. User gives you a task
@@ -94,32 +163,62 @@ CORRECT: [Immediately call read_file function]
 
 NEVER describe what you're going to do - just do it by calling functions immediately.`
 
+	if _, ok := a.toolRegistry.Get("find_definition"); ok {
+		basePrompt += `
+
+This is a Go project, so you also have symbol-aware navigation:
+- find_definition: Find where a symbol is declared, with its signature
+- find_references: Find every use of a symbol using type information
+- list_symbols: List package-level symbols with file:line and signature
+- outline_file: Show the declarations in a Go file
+
+Prefer these over grep_search when looking for Go identifiers.`
+	}
+
+	if instructions := a.instructionsContext(); instructions != "" {
+		basePrompt += "\n\n" + instructions
+	}
+
+	if a.sessionHookContext != "" {
+		basePrompt += "\n\nSESSION START HOOKS:\n" + a.sessionHookContext
+	}
+
 	// Add dynamic session context
 	contextInfo := a.buildContextInfo()
 	if contextInfo != "" {
-		return basePrompt + "\n\n" + contextInfo
+		basePrompt += "\n\n" + contextInfo
+	}
+
+	if repoMap := a.repoMapContext(a.repoMapQuery); repoMap != "" {
+		basePrompt += "\n" + repoMap
 	}
 	return basePrompt
 }
 
 func (a *Agent) ProcessMessage(userMessage string) (string, error) {
+	input := a.hookInput(hooks.UserPromptSubmit)
+	input.Prompt = userMessage
+	submit := a.runHooks(input)
+	if submit.Blocked {
+		return "", fmt.Errorf("prompt blocked by hook: %s", submit.Reason)
+	}
+
 	messages := a.GetConversationHistory()
 	
 	if len(messages) == 0 {
+		a.repoMapQuery = userMessage
 		a.AddMessage("system", a.GetSystemPrompt())
 	}
 
+	a.contextWindow.StartTurn()
+	a.startVerifyTurn()
+	if len(submit.Messages) > 0 {
+		a.AddMessage("system", "Context from user_prompt_submit hooks:\n"+strings.Join(submit.Messages, "\n"))
+	}
 	a.AddMessage("user", userMessage)
+	a.gitState.turnMessage = userMessage
 
-	availableTools := a.getOpenRouterTools()
-	messages = a.GetConversationHistory()
-	
-	response, err := a.client.Chat(
-		messages,
-		availableTools,
-		a.Config.Agent.MaxTokens,
-		a.Config.Agent.Temperature,
-	)
+	response, err := a.requestCompletion()
 	if err != nil {
 		return "", fmt.Errorf("failed to get AI response: %w", err)
 	}
@@ -134,11 +233,16 @@ func (a *Agent) ProcessMessage(userMessage string) (string, error) {
 
 	if len(message.ToolCalls) > 0 {
 		response, err := a.executeOpenRouterToolCalls(message.ToolCalls, aiResponse)
+		a.commitTurnChanges()
+		a.capturePlan()
+		a.runTurnEndHooks(response)
 		// Auto-save session after tool execution
 		a.autoSaveSession()
 		return response, err
 	}
 
+	a.capturePlan()
+	a.runTurnEndHooks(aiResponse)
 	// Auto-save session after regular message processing
 	a.autoSaveSession()
 	return aiResponse, nil
@@ -159,17 +263,21 @@ func (a *Agent) ProcessMessageStream(userMessage string, callback func(string))
 
 func (a *Agent) getOpenRouterTools() []openrouter.Tool {
 	availableTools := a.toolRegistry.List()
-	orTools := make([]openrouter.Tool, len(availableTools))
+	orTools := make([]openrouter.Tool, 0, len(availableTools))
 
-	for i, tool := range availableTools {
-		orTools[i] = openrouter.Tool{
+	for _, tool := range availableTools {
+		// Don't offer tools the permission policy would refuse without asking
+		if a.policy != nil && a.approver == nil && !a.policy.Permits(tool) {
+			continue
+		}
+		orTools = append(orTools, openrouter.Tool{
 			Type: "function",
 			Function: openrouter.ToolFunction{
 				Name:        tool.Name(),
 				Description: tool.Description(),
 				Parameters:  a.convertSchema(tool.Schema()),
 			},
-		}
+		})
 	}
 
 	return orTools
@@ -206,52 +314,58 @@ func (a *Agent) executeOpenRouterToolCalls(toolCalls []openrouter.ToolCall, orig
 		toolDisplay := ui.NewToolExecutionDisplay(len(currentToolCalls))
 
 		var toolResults []string
-		for _, toolCall := range currentToolCalls {
-			var args map[string]interface{}
-			argStr := strings.TrimSpace(toolCall.Function.Arguments)
-			if argStr == "" || argStr == "{}" {
-				args = make(map[string]interface{})
-			} else {
-				if err := json.Unmarshal([]byte(argStr), &args); err != nil {
-					toolDisplay.StartTool(toolCall.Function.Name, make(map[string]interface{}))
-					toolDisplay.FinishTool(false, "", fmt.Errorf("failed to parse arguments '%s': %v", argStr, err))
-					continue
-				}
+		var toolOutputs []context.ToolOutput
+		newInstructions := false
+		a.runToolCalls(currentToolCalls, toolDisplay, func(exec *toolExecution) {
+			if exec.parseErr != nil {
+				toolResults = append(toolResults, fmt.Sprintf("%s error: %v", exec.name, exec.parseErr))
+				return
 			}
+			result := exec.result
 
-			toolDisplay.StartTool(toolCall.Function.Name, args)
-			
-			result := a.toolRegistry.Execute(toolCall.Function.Name, args)
+			// Files changed by tools that don't name them count whether or
+			// not the tool succeeded
+			for _, path := range exec.changed {
+				a.noteFileChanged(path)
+			}
 
 			// Automatically track files for file-related operations
 			if result.Success {
-				a.trackFileOperation(toolCall.Function.Name, args)
-			}
-
-			var err error
-			if !result.Success && result.Error != "" {
-				err = fmt.Errorf("%s", result.Error)
+				a.trackFileOperation(exec.name, exec.args)
+				if path, ok := exec.args["path"].(string); ok && path != "" {
+					if a.loadNestedInstructions(path) {
+						newInstructions = true
+					}
+				}
 			}
-			toolDisplay.FinishTool(result.Success, result.Result, err)
 
 			if result.Success {
-				toolResults = append(toolResults, fmt.Sprintf("%s result: %s", result.Name, result.Result))
+				output := fmt.Sprintf("%s result: %s", result.Name, result.Result)
+				toolResults = append(toolResults, output)
+				toolOutputs = append(toolOutputs, context.ToolOutput{
+					Tool:        result.Name,
+					Content:     output,
+					Placeholder: toolResultPlaceholder(result.Name, exec.args, result.Result),
+				})
 			} else {
 				toolResults = append(toolResults, fmt.Sprintf("%s error: %s", result.Name, result.Error))
 			}
-		}
+		})
 		
 		toolDisplay.ShowToolSummary()
 
+		// Directory-specific instructions take effect as soon as files there are
+		// touched. They join the system prompt, which lists every loaded
+		// instruction file, so they outlast trimming and /clear.
+		if newInstructions {
+			a.contextWindow.UpdateSystemMessage(a.GetSystemPrompt())
+		}
+
 		toolResultsMessage := strings.Join(toolResults, "\n")
-		a.AddMessage("user", fmt.Sprintf("Tool execution results:\n%s\n\nIMPORTANT: If the task is not complete, you MUST call the next function immediately. Do not describe what you want to do - just call the function. Only provide a summary if the task is 100%% complete.", toolResultsMessage))
-
-		followUpResponse, err := a.client.Chat(
-			a.GetConversationHistory(),
-			a.getOpenRouterTools(),
-			a.Config.Agent.MaxTokens,
-			a.Config.Agent.Temperature,
-		)
+		toolResultsContent := fmt.Sprintf("Tool execution results:\n%s\n\nIMPORTANT: If the task is not complete, you MUST call the next function immediately. Do not describe what you want to do - just call the function. Only provide a summary if the task is 100%% complete.", toolResultsMessage)
+		a.contextWindow.AddToolResults("user", toolResultsContent, false, toolOutputs)
+
+		followUpResponse, err := a.requestCompletion()
 		if err != nil {
 			results = append(results, fmt.Sprintf("\nFailed to get follow-up response: %v", err))
 			break
@@ -269,13 +383,32 @@ func (a *Agent) executeOpenRouterToolCalls(toolCalls []openrouter.ToolCall, orig
 		if len(followUpMessage.ToolCalls) > 0 {
 			currentToolCalls = followUpMessage.ToolCalls
 		} else {
-			break
+			// Before accepting the final summary, check that the changes
+			// build and pass; failures give the model another round
+			currentToolCalls = a.verifyTurn(&results)
 		}
 	}
 
+	if summary := a.verifySummary(); summary != "" {
+		results = append(results, summary)
+	}
 	return strings.Join(results, "\n"), nil
 }
 
+// parseToolArguments decodes a tool call's JSON arguments, treating an empty
+// string as no arguments
+func parseToolArguments(raw string) (map[string]interface{}, error) {
+	args := make(map[string]interface{})
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "{}" {
+		return args, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &args); err != nil {
+		return nil, fmt.Errorf("failed to parse arguments '%s': %v", raw, err)
+	}
+	return args, nil
+}
+
 func (a *Agent) ClearConversation() {
 	a.contextWindow.ClearConversation()
 }
@@ -347,6 +480,27 @@ func (a *Agent) GetContextStats() string {
 	return a.contextWindow.GetContextStats()
 }
 
+// PinMessage pins a message by ID so it survives trimming and /clear
+func (a *Agent) PinMessage(messageID string) error {
+	return a.contextWindow.Pin(messageID)
+}
+
+// UnpinMessage unpins a message by ID, or every message for "all"
+func (a *Agent) UnpinMessage(messageID string) (int, error) {
+	if messageID == "all" {
+		return a.contextWindow.UnpinAll(), nil
+	}
+	if err := a.contextWindow.Unpin(messageID); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// ListMessages describes the most recent messages with their IDs
+func (a *Agent) ListMessages(limit int) string {
+	return a.contextWindow.ListMessages(limit)
+}
+
 func (a *Agent) GetContextUsagePercentage() float64 {
 	return a.contextWindow.GetUsagePercentage()
 }
@@ -417,6 +571,15 @@ func (a *Agent) buildContextInfo() string {
 			}
 		}
 	}
+
+	// Languages, sub-projects and the commands for the one being edited
+	if layout := a.sessionContext.Layout; layout != nil {
+		activePath := a.sessionContext.WorkingDir
+		if len(a.sessionContext.FocusedFiles) > 0 {
+			activePath = a.sessionContext.FocusedFiles[0]
+		}
+		info.WriteString(layout.Summary(activePath))
+	}
 	
 
 	
@@ -440,6 +603,14 @@ func (a *Agent) buildContextInfo() string {
 
 // trackFileOperation automatically tracks files when tools interact with them
 func (a *Agent) trackFileOperation(toolName string, args map[string]interface{}) {
+	switch toolName {
+	case "write_file", "edit_file", "replace_content":
+		if path, ok := args["path"].(string); ok && path != "" {
+			a.recordChangedFile(path)
+			a.noteFileChanged(path)
+		}
+	}
+
 	switch toolName {
 	case "read_file", "write_file":
 		if path, ok := args["path"].(string); ok && path != "" {
@@ -449,8 +620,10 @@ func (a *Agent) trackFileOperation(toolName string, args map[string]interface{})
 			}
 			a.sessionContext.AddFocusedFile(path)
 			a.sessionContext.AddRecentFile(path)
+			// The model now has this content, so it is not stale
+			a.markFileSeen(path)
 		}
-	case "edit_file":
+	case "edit_file", "replace_content":
 		if path, ok := args["path"].(string); ok && path != "" {
 			if absPath, err := filepath.Abs(path); err == nil {
 				path = absPath
@@ -480,18 +653,16 @@ func (a *Agent) trackFileOperation(toolName string, args map[string]interface{})
 
 // autoSaveSession automatically saves the current session to a temporary file
 func (a *Agent) autoSaveSession() {
-	// Create a session file in the user's home directory or temp directory
-	sessionFile := a.getSessionFilePath()
-	if sessionFile == "" {
-		return // Skip if we can't determine a good location
+	if a.sessionFile == "" {
+		return // Skip sessions that aren't kept
 	}
 	
 	// Save session context (ignore errors for auto-save)
-	a.sessionContext.SaveToFile(sessionFile)
+	a.sessionContext.SaveToFile(a.sessionFile)
 }
 
-// getSessionFilePath returns the path where session should be saved
-func (a *Agent) getSessionFilePath() string {
+// defaultSessionFile returns the path where session should be saved
+func defaultSessionFile() string {
 	// Try user's home directory first
 	if homeDir, err := os.UserHomeDir(); err == nil {
 		return filepath.Join(homeDir, ".agent_go_session.json")
@@ -503,7 +674,7 @@ func (a *Agent) getSessionFilePath() string {
 
 // LoadSession attempts to load a previously saved session
 func (a *Agent) LoadSession() {
-	sessionFile := a.getSessionFilePath()
+	sessionFile := a.sessionFile
 	if sessionFile == "" {
 		return
 	}
@@ -516,8 +687,14 @@ func (a *Agent) LoadSession() {
 	// Load the session (ignore errors - we'll just start fresh if loading fails)
 	if loadedSession, err := context.LoadFromFile(sessionFile); err == nil {
 		a.sessionContext = loadedSession
-		// Re-detect project type in case the project has changed
-		a.sessionContext.DetectProjectType()
+		// Tools resolve paths against the process directory, so the saved
+		// working directory must not override it. This also re-detects the
+		// project type in case the project has changed.
+		if wd, err := os.Getwd(); err == nil {
+			a.sessionContext.SetWorkingDir(wd)
+		} else {
+			a.sessionContext.DetectProjectType()
+		}
 	}
 }
 
@@ -542,25 +719,19 @@ func getModelContextLimit(model string) int {
 	}
 }
 
-func isImportantMessage(role, content string) bool {
-	if role == "system" {
-		return true
+// shouldAutoPin reports whether a chat message matches one of the configured
+// auto-pin keywords. System messages are the prompt, which is always kept, or
+// transient notes, so they are never pinned automatically.
+func (a *Agent) shouldAutoPin(role, content string) bool {
+	if role == "system" || len(a.Config.Agent.AutoPinKeywords) == 0 {
+		return false
 	}
-	
+
 	content = strings.ToLower(content)
-	
-	importantKeywords := []string{
-		"error", "failed", "success", "completed",
-		"implement", "create", "build", "deploy",
-		"task:", "goal:", "objective:",
-		"important:", "note:", "warning:",
-	}
-	
-	for _, keyword := range importantKeywords {
-		if strings.Contains(content, keyword) {
+	for _, keyword := range a.Config.Agent.AutoPinKeywords {
+		if keyword != "" && strings.Contains(content, strings.ToLower(keyword)) {
 			return true
 		}
 	}
-	
 	return false
-}
\ No newline at end of file
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// toolResultPlaceholder is the short stand-in for a tool result once it has
+// been compacted, telling the model what it was and how to get it back
+func toolResultPlaceholder(tool string, args map[string]interface{}, result string) string {
+	arg := func(key string) string {
+		value, _ := args[key].(string)
+		return value
+	}
+	lines := strings.Count(strings.TrimRight(result, "\n"), "\n") + 1
+
+	var summary string
+	switch tool {
+	case "read_file":
+		summary = fmt.Sprintf("read %s, %d lines - re-read if needed", arg("path"), lines)
+	case "grep_search", "search_code":
+		summary = fmt.Sprintf("searched %s for '%s', %d lines of matches - re-run if needed", arg("path"), arg("pattern"), lines)
+	case "search_codebase":
+		summary = fmt.Sprintf("searched the codebase for '%s', %d lines of results - re-run if needed", arg("query"), lines)
+	case "find_files":
+		summary = fmt.Sprintf("found files matching '%s' in %s, %d lines - re-run if needed", arg("pattern"), arg("path"), lines)
+	case "list_directory":
+		summary = fmt.Sprintf("listed %s, %d entries - re-list if needed", arg("path"), lines)
+	case "run_command":
+		summary = fmt.Sprintf("ran `%s`, %d lines of output - re-run if needed", arg("command"), lines)
+	case "run_tests":
+		summary = fmt.Sprintf("test results (%s) - re-run if needed", strings.SplitN(result, "\n", 2)[0])
+	case "delegate":
+		summary = fmt.Sprintf("sub-agent report, %d lines - delegate again if needed", lines)
+	case "todo_write", "todo_read":
+		summary = "checklist shown - the current list is sent with each request"
+	case "find_definition", "find_references":
+		summary = fmt.Sprintf("looked up '%s', %d lines - re-run if needed", arg("symbol"), lines)
+	default:
+		summary = fmt.Sprintf("%d lines of output - re-run if needed", lines)
+	}
+
+	return fmt.Sprintf("%s result: [compacted] %s", tool, summary)
+}
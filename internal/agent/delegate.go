@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ttli3/go-coding-agent/internal/context"
+	"github.com/ttli3/go-coding-agent/internal/openrouter"
+	"github.com/ttli3/go-coding-agent/internal/tools"
+)
+
+const (
+	// defaultDelegateMaxSteps bounds a sub-agent's tool rounds when unconfigured
+	defaultDelegateMaxSteps = 15
+	// maxConcurrentDelegates bounds how many sub-agents run at once
+	maxConcurrentDelegates = 4
+)
+
+// delegatePrompt is the system prompt of a sub-agent
+const delegatePrompt = `You are a research sub-agent working for another coding agent. Investigate the task you are given using the read-only tools available (reading, searching, git inspection). You cannot change files or run commands.
+
+Call tools immediately instead of describing what you will do. When you have the answer, reply WITHOUT calling tools with a concise report: the findings, the relevant file:line locations, and anything you are unsure about. The report is all the other agent will see, so include the specifics it needs but no raw dumps of files or search results.`
+
+// delegateExcluded are read-only tools a sub-agent must not get: it cannot
+// delegate further, and the checklist belongs to the parent
+var delegateExcluded = map[string]bool{
+	"delegate":  true,
+	"todo_read": true,
+}
+
+// registerDelegateTool lets the model hand investigations to sub-agents
+func (a *Agent) registerDelegateTool() {
+	a.toolRegistry.Register(tools.NewDelegateTool(a))
+}
+
+// Delegate runs each task in its own sub-agent, concurrently, and returns
+// their final reports
+func (a *Agent) Delegate(tasks []string) (string, error) {
+	children := make([]*Agent, len(tasks))
+	for i := range tasks {
+		children[i] = a.newSubAgent()
+	}
+
+	reports := make([]string, len(tasks))
+	slots := make(chan struct{}, maxConcurrentDelegates)
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func(i int, task string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			report, err := children[i].runDelegated(task)
+			if err != nil {
+				report = fmt.Sprintf("Investigation failed: %v", err)
+			}
+			reports[i] = report
+		}(i, task)
+	}
+	wg.Wait()
+
+	for _, child := range children {
+		a.addUsage(child.Usage())
+	}
+
+	if len(tasks) == 1 {
+		return reports[0], nil
+	}
+
+	var out strings.Builder
+	for i, task := range tasks {
+		out.WriteString(fmt.Sprintf("## Task %d: %s\n%s\n\n", i+1, task, reports[i]))
+	}
+	return strings.TrimRight(out.String(), "\n"), nil
+}
+
+// newSubAgent creates a child agent with its own context window and session
+// and only the parent's read-only tools that its policy permits
+func (a *Agent) newSubAgent() *Agent {
+	contextWindow := context.NewContextWindow(getModelContextLimit(a.Config.OpenRouter.Model))
+	contextWindow.SetCompactionPolicy(context.NewCompactionPolicy(a.Config.Agent.CompactAfterTurns))
+
+	return &Agent{
+		client: a.client,
+		toolRegistry: a.toolRegistry.Subset(func(tool tools.Tool) bool {
+			return tools.IsReadOnly(tool) && !delegateExcluded[tool.Name()] &&
+				(a.policy == nil || a.policy.Permits(tool))
+		}),
+		policy:         a.policy,
+		Config:         a.Config,
+		sessionContext: a.sessionContext.Fork(),
+		contextWindow:  contextWindow,
+		hooks:          a.hooks,
+	}
+}
+
+// runDelegated works on task until the model replies without tool calls or
+// runs out of steps, and returns that reply as the report. Unlike
+// ProcessMessage it prints nothing and never saves the session.
+func (a *Agent) runDelegated(task string) (string, error) {
+	prompt := delegatePrompt
+	if root := a.projectRoot(); root != "" {
+		prompt += fmt.Sprintf("\n\nProject root: %s", root)
+	}
+	a.AddMessage("system", prompt)
+	a.AddMessage("user", task)
+
+	maxSteps := a.Config.Agent.DelegateMaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultDelegateMaxSteps
+	}
+
+	for step := 0; step < maxSteps; step++ {
+		message, err := a.chatOnce(a.getOpenRouterTools())
+		if err != nil {
+			return "", err
+		}
+		a.AddMessage("assistant", message.Content)
+		if len(message.ToolCalls) == 0 {
+			return strings.TrimSpace(message.Content), nil
+		}
+
+		var results []string
+		for _, toolCall := range message.ToolCalls {
+			args, err := parseToolArguments(toolCall.Function.Arguments)
+			if err != nil {
+				results = append(results, fmt.Sprintf("%s error: %v", toolCall.Function.Name, err))
+				continue
+			}
+			result := a.executeTool(&toolExecution{name: toolCall.Function.Name, args: args})
+			if result.Success {
+				results = append(results, fmt.Sprintf("%s result: %s", result.Name, result.Result))
+			} else {
+				results = append(results, fmt.Sprintf("%s error: %s", result.Name, result.Error))
+			}
+		}
+		a.AddMessage("user", "Tool execution results:\n"+strings.Join(results, "\n"))
+	}
+
+	// Out of steps: ask for the report from what was found so far
+	a.AddMessage("user", "You have used all your tool calls. Write your report now from what you found, noting what is still unknown.")
+	message, err := a.chatOnce(nil)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(message.Content), nil
+}
+
+// chatOnce sends the conversation and returns the model's reply
+func (a *Agent) chatOnce(availableTools []openrouter.Tool) (openrouter.Message, error) {
+	response, err := a.client.Chat(
+		a.GetConversationHistory(),
+		availableTools,
+		a.Config.Agent.MaxTokens,
+		a.Config.Agent.Temperature,
+	)
+	if err != nil {
+		return openrouter.Message{}, fmt.Errorf("sub-agent request failed: %w", err)
+	}
+	a.addUsage(response.Usage)
+	if len(response.Choices) == 0 {
+		return openrouter.Message{}, fmt.Errorf("no response from AI")
+	}
+	return response.Choices[0].Message, nil
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"github.com/ttli3/go-coding-agent/internal/openrouter"
+)
+
+// Event types reported while a message is processed
+const (
+	EventMessage     = "message"          // a reply from the model
+	EventToolCall    = "tool_call"        // the model asked for a tool
+	EventToolResult  = "tool_result"      // a tool finished
+	EventApproval    = "approval_request" // a tool call outside the policy awaits an answer
+	EventFileChanged = "file_changed"     // a tool changed a file
+)
+
+// Event describes the progress of a turn, for callers that run the agent
+// without a terminal
+type Event struct {
+	Type    string                 `json:"type"`
+	Role    string                 `json:"role,omitempty"`
+	Content string                 `json:"content,omitempty"`
+	ID      string                 `json:"id,omitempty"` // links a tool call to its result
+	Tool    string                 `json:"tool,omitempty"`
+	Args    map[string]interface{} `json:"args,omitempty"`
+	IsError bool                   `json:"is_error,omitempty"`
+	Usage   *openrouter.Usage      `json:"usage,omitempty"`
+	Path    string                 `json:"path,omitempty"` // the file changed, relative to the project root when inside it
+}
+
+// SetEventHandler registers a function called with every event, or removes
+// it when handler is nil. It is called from the goroutine processing the
+// message.
+func (a *Agent) SetEventHandler(handler func(Event)) {
+	a.events = handler
+}
+
+func (a *Agent) emit(event Event) {
+	if a.events != nil {
+		a.events(event)
+	}
+}
+
+// Usage returns the tokens used by the conversation's requests so far,
+// including those of delegated sub-agents
+func (a *Agent) Usage() openrouter.Usage {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+
+	return a.usage
+}
+
+// addUsage counts the tokens of a request
+func (a *Agent) addUsage(usage openrouter.Usage) {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+
+	a.usage.PromptTokens += usage.PromptTokens
+	a.usage.CompletionTokens += usage.CompletionTokens
+	a.usage.TotalTokens += usage.TotalTokens
+}
+
+// requestCompletion sends the conversation and the available tools to the
+// model, recording token usage and reporting the reply
+func (a *Agent) requestCompletion() (*openrouter.ChatResponse, error) {
+	response, err := a.client.Chat(
+		a.requestMessages(),
+		a.getOpenRouterTools(),
+		a.Config.Agent.MaxTokens,
+		a.Config.Agent.Temperature,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	a.addUsage(response.Usage)
+
+	if len(response.Choices) > 0 {
+		usage := response.Usage
+		a.emit(Event{
+			Type:    EventMessage,
+			Role:    "assistant",
+			Content: response.Choices[0].Message.Content,
+			Usage:   &usage,
+		})
+	}
+	return response, nil
+}
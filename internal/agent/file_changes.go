@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ttli3/go-coding-agent/internal/git"
+	"github.com/ttli3/go-coding-agent/internal/project"
+	"github.com/ttli3/go-coding-agent/internal/tools"
+)
+
+// fileStamp is what a snapshot remembers about a file
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// worktreeSnapshot maps the files git lists as changed to their stamps
+type worktreeSnapshot map[string]fileStamp
+
+// tracksChangesOf reports whether a tool's changes are found by comparing
+// snapshots: tools that may change files without naming them, such as
+// run_command, plugins, MCP tools and delegate
+func (a *Agent) tracksChangesOf(name string) bool {
+	if goEditTools[name] {
+		return false
+	}
+	tool, ok := a.toolRegistry.Get(name)
+	return ok && !tools.IsReadOnly(tool)
+}
+
+// snapshotWorktree records the project's changed files, or returns nil
+// outside a git repository
+func (a *Agent) snapshotWorktree() worktreeSnapshot {
+	files, err := git.DirtyFiles(a.projectRoot())
+	if err != nil {
+		return nil
+	}
+	snapshot := make(worktreeSnapshot, len(files))
+	for _, path := range files {
+		snapshot[path] = stampFile(path)
+	}
+	return snapshot
+}
+
+// changedSince returns the files that became changed, changed again or were
+// restored since the snapshot was taken
+func (a *Agent) changedSince(before worktreeSnapshot) []string {
+	after := a.snapshotWorktree()
+	if before == nil || after == nil {
+		return nil
+	}
+	var changed []string
+	for path, stamp := range after {
+		if previous, ok := before[path]; !ok || previous != stamp {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+func stampFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, size: info.Size(), modTime: info.ModTime()}
+}
+
+// noteFileChanged records a file changed during the turn, for verification,
+// the current task and the event handler
+func (a *Agent) noteFileChanged(path string) {
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	a.recordTurnFile(path)
+	a.sessionContext.RecordFileChange(a.displayPath(path))
+	// A new or changed build file can add or change a sub-project
+	if project.IsMarkerFile(filepath.Base(path)) {
+		project.Invalidate()
+		a.sessionContext.DetectProjectType()
+	}
+	a.emit(Event{Type: EventFileChanged, Path: a.displayPath(path)})
+}
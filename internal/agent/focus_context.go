@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ttli3/go-coding-agent/internal/context"
+	"github.com/ttli3/go-coding-agent/internal/openrouter"
+	"github.com/ttli3/go-coding-agent/internal/repomap"
+	"github.com/ttli3/go-coding-agent/internal/tools"
+	"github.com/ttli3/go-coding-agent/internal/walker"
+)
+
+// maxFocusFileSize is the largest file whose content is considered for injection
+const maxFocusFileSize = 512 * 1024
+
+// requestMessages returns the conversation for the next request with the
+// plan mode rules, the unfinished todo list and the current contents of the
+// focused files inserted before the latest message. The injected message is rebuilt for every
+// request and never stored.
+func (a *Agent) requestMessages() []openrouter.Message {
+	messages := a.GetConversationHistory()
+
+	var parts []string
+	for _, part := range []string{a.planModeContext(), a.todoContext(), a.focusedFilesContext()} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 || len(messages) == 0 {
+		return messages
+	}
+
+	last := len(messages) - 1
+	withContext := make([]openrouter.Message, 0, len(messages)+1)
+	withContext = append(withContext, messages[:last]...)
+	withContext = append(withContext, openrouter.Message{Role: "system", Content: strings.Join(parts, "\n\n")})
+	return append(withContext, messages[last])
+}
+
+// focusedFilesContext renders focused files within the focus token budget:
+// full content with line numbers when it fits, otherwise an outline. Files
+// whose content changed since the model last saw them are flagged.
+func (a *Agent) focusedFilesContext() string {
+	budget := a.Config.Agent.FocusTokens
+	if budget <= 0 || len(a.sessionContext.FocusedFiles) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString("FOCUSED FILES (current content on disk; line numbers are accurate as of this message):\n")
+	used := context.EstimateTokens(out.String())
+	included := 0
+
+	for _, path := range a.sessionContext.FocusedFiles {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() || info.Size() > maxFocusFileSize {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || walker.IsBinary(content) {
+			continue
+		}
+
+		sum := sha256.Sum256(content)
+		hash := hex.EncodeToString(sum[:])
+		lastSeen, seen := a.sessionContext.LastSeenHash(path)
+		changed := seen && lastSeen != hash
+
+		display := a.displayPath(path)
+		header := fmt.Sprintf("\n=== %s ===\n", display)
+		if changed {
+			header = fmt.Sprintf("\n=== %s (CHANGED since you last saw it - discard earlier line numbers) ===\n", display)
+		}
+
+		body := numberLines(string(content))
+		full := true
+		if used+context.EstimateTokens(header+body) > budget {
+			body = fileOutline(path)
+			full = false
+			if body == "" || used+context.EstimateTokens(header+body) > budget {
+				continue
+			}
+		}
+
+		out.WriteString(header)
+		out.WriteString(body)
+		if !full {
+			out.WriteString("(outline only: the file is too large for the focus budget; use read_file for its content)\n")
+		}
+		used += context.EstimateTokens(header + body)
+		included++
+
+		// Only full content counts as seen; an outline doesn't refresh line numbers
+		if full {
+			a.sessionContext.MarkFileSeen(path, hash)
+		}
+	}
+
+	if included == 0 {
+		return ""
+	}
+	return out.String()
+}
+
+// markFileSeen records that the model has seen the current content of a file,
+// e.g. because it just read it
+func (a *Agent) markFileSeen(path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(content)
+	a.sessionContext.MarkFileSeen(path, hex.EncodeToString(sum[:]))
+}
+
+// fileOutline summarizes a file's declarations for files too large to include
+func fileOutline(path string) string {
+	if strings.HasSuffix(path, ".go") {
+		if outline, err := tools.GoFileOutline(path); err == nil {
+			return outline
+		}
+	}
+
+	symbols := repomap.ExtractSymbols(path)
+	if len(symbols) == 0 {
+		return ""
+	}
+	return "  " + strings.Join(symbols, "\n  ") + "\n"
+}
+
+// numberLines prefixes each line with its 1-based line number
+func numberLines(content string) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	width := len(fmt.Sprint(len(lines)))
+
+	var out strings.Builder
+	for i, line := range lines {
+		out.WriteString(fmt.Sprintf("%*d| %s\n", width, i+1, line))
+	}
+	return out.String()
+}
+
+// displayPath shows paths inside the project relative to its root
+func (a *Agent) displayPath(path string) string {
+	if rel, err := filepath.Rel(a.projectRoot(), path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
@@ -0,0 +1,323 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/ttli3/go-coding-agent/internal/git"
+	"github.com/ttli3/go-coding-agent/internal/openrouter"
+)
+
+// wipCommitPrefix marks commits created automatically after a turn
+const wipCommitPrefix = "wip(agent): "
+
+// maxCommitDiffChars caps how much diff is sent to the model for a commit message
+const maxCommitDiffChars = 12000
+
+const commitMessagePrompt = `You write git commit messages. Given a diff and the request that produced it, reply with ONLY the commit message:
+- a summary line in the imperative mood, at most 72 characters, no trailing period
+- optionally a blank line followed by a short body explaining why
+Do not wrap the message in quotes or code fences.`
+
+// gitCommitState tracks commits the agent made during this session
+type gitCommitState struct {
+	autoCommit   bool
+	branch       string
+	baseCommit   string   // HEAD before the first agent commit
+	commits      []string // agent commit hashes, oldest first
+	pendingFiles []string // files modified by the agent but not yet committed
+	turnMessage  string   // user message that started the current turn
+}
+
+// recordChangedFile notes a file the agent modified so it can be committed later
+func (a *Agent) recordChangedFile(path string) {
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	for _, f := range a.gitState.pendingFiles {
+		if f == path {
+			return
+		}
+	}
+	a.gitState.pendingFiles = append(a.gitState.pendingFiles, path)
+}
+
+// IsAutoCommitEnabled reports whether changes are committed after each turn
+func (a *Agent) IsAutoCommitEnabled() bool {
+	return a.gitState.autoCommit
+}
+
+// SetAutoCommit turns per-turn WIP commits on or off
+func (a *Agent) SetAutoCommit(enabled bool) {
+	a.gitState.autoCommit = enabled
+}
+
+// commitTurnChanges creates a WIP commit for files modified during the turn
+func (a *Agent) commitTurnChanges() {
+	if !a.gitState.autoCommit || len(a.gitState.pendingFiles) == 0 {
+		return
+	}
+
+	hash, message, err := a.commitPendingFiles()
+	if err != nil {
+		color.New(color.FgRed).Printf("[GIT] Auto-commit failed: %v\n", err)
+		return
+	}
+	if hash == "" {
+		return
+	}
+
+	a.gitState.commits = append(a.gitState.commits, hash)
+	subject := strings.SplitN(message, "\n", 2)[0]
+	color.New(color.FgHiBlack).Printf("[GIT] Committed %s %s\n", hash[:8], subject)
+}
+
+// commitPendingFiles commits the agent's pending files as a WIP commit
+func (a *Agent) commitPendingFiles() (string, string, error) {
+	dir := a.sessionContext.WorkingDir
+	root, err := git.TopLevel(dir)
+	if err != nil {
+		return "", "", fmt.Errorf("not a git repository")
+	}
+
+	files := git.FilesInRepo(root, a.gitState.pendingFiles)
+	a.gitState.pendingFiles = nil
+	if len(files) == 0 {
+		return "", "", nil
+	}
+
+	if err := a.ensureCommitBranch(root); err != nil {
+		return "", "", err
+	}
+
+	changed, err := git.StageFiles(root, files)
+	if err != nil || !changed {
+		return "", "", err
+	}
+
+	if a.gitState.baseCommit == "" {
+		if head, err := git.Head(root); err == nil {
+			a.gitState.baseCommit = head
+		}
+	}
+
+	message := wipCommitPrefix + a.generateCommitMessage(root, files, "update "+describeFiles(root, files))
+	hash, err := git.CommitFiles(root, message, files)
+	if err != nil {
+		return "", "", err
+	}
+	return hash, message, nil
+}
+
+// ensureCommitBranch switches to the configured agent branch before the first
+// commit. An existing branch is only reused when HEAD already contains it, and
+// is then moved up to HEAD, so switching never changes the working tree.
+func (a *Agent) ensureCommitBranch(root string) error {
+	if a.gitState.branch == "" {
+		return nil
+	}
+
+	current, err := git.CurrentBranch(root)
+	if err != nil || current == a.gitState.branch {
+		return err
+	}
+
+	if git.BranchExists(root, a.gitState.branch) && !git.IsAncestor(root, a.gitState.branch, "HEAD") {
+		return fmt.Errorf("branch %s has commits that HEAD doesn't; delete it or set another git.commit_branch", a.gitState.branch)
+	}
+	_, err = git.Run(root, "checkout", "-B", a.gitState.branch)
+	return err
+}
+
+// CommitAgentChanges squashes the agent's WIP commits, plus any uncommitted agent
+// changes, into a single commit with a proper message
+func (a *Agent) CommitAgentChanges(message string) (string, error) {
+	dir := a.sessionContext.WorkingDir
+	root, err := git.TopLevel(dir)
+	if err != nil {
+		return "", fmt.Errorf("not a git repository")
+	}
+
+	files := git.FilesInRepo(root, a.gitState.pendingFiles)
+
+	if len(a.gitState.commits) > 0 {
+		if err := a.verifyAgentCommitsAtHead(root); err != nil {
+			return "", err
+		}
+
+		committed, err := git.Run(root, "diff", "--name-only", a.gitState.baseCommit, "HEAD")
+		if err != nil {
+			return "", err
+		}
+		for _, name := range strings.Split(strings.TrimSpace(committed), "\n") {
+			if name != "" {
+				files = appendUnique(files, filepath.Join(root, name))
+			}
+		}
+
+		if _, err := git.Run(root, "reset", "--soft", a.gitState.baseCommit); err != nil {
+			return "", err
+		}
+	}
+
+	if len(files) == 0 {
+		return "No agent changes to commit", nil
+	}
+
+	changed, err := git.StageFiles(root, files)
+	if err != nil {
+		return "", err
+	}
+	if !changed {
+		a.resetCommitState()
+		return "Agent changes cancel out; nothing to commit", nil
+	}
+
+	if message == "" {
+		message = a.generateCommitMessage(root, files, "Update "+describeFiles(root, files))
+	}
+
+	hash, err := git.CommitFiles(root, message, files)
+	if err != nil {
+		return "", err
+	}
+
+	squashed := len(a.gitState.commits)
+	a.resetCommitState()
+
+	subject := strings.SplitN(message, "\n", 2)[0]
+	if squashed > 1 {
+		return fmt.Sprintf("Squashed %d agent commits into %s: %s", squashed, hash[:8], subject), nil
+	}
+	return fmt.Sprintf("Committed %s: %s", hash[:8], subject), nil
+}
+
+// RollbackLastCommit undoes the most recent agent commit
+func (a *Agent) RollbackLastCommit() (string, error) {
+	if len(a.gitState.commits) == 0 {
+		return "", fmt.Errorf("no agent commits to roll back")
+	}
+
+	dir := a.sessionContext.WorkingDir
+	root, err := git.TopLevel(dir)
+	if err != nil {
+		return "", fmt.Errorf("not a git repository")
+	}
+
+	last := a.gitState.commits[len(a.gitState.commits)-1]
+	head, err := git.Head(root)
+	if err != nil {
+		return "", err
+	}
+
+	if head == last {
+		// Drop the commit and restore the files; --keep refuses to clobber local edits
+		if _, err := git.Run(root, "reset", "--keep", "HEAD~1"); err != nil {
+			return "", err
+		}
+	} else {
+		// Someone committed on top, so keep history and revert instead
+		if _, err := git.Run(root, "revert", "--no-edit", last); err != nil {
+			return "", err
+		}
+	}
+
+	if head == last {
+		a.gitState.commits = a.gitState.commits[:len(a.gitState.commits)-1]
+		if len(a.gitState.commits) == 0 {
+			a.gitState.baseCommit = ""
+		}
+		return fmt.Sprintf("Rolled back agent commit %s", last[:8]), nil
+	}
+
+	// The agent's commits are now interleaved with other history, so later
+	// commits start a new series from the revert
+	a.gitState.commits = nil
+	a.gitState.baseCommit = ""
+	return fmt.Sprintf("Reverted agent commit %s with a new commit; earlier agent commits are kept as they are", last[:8]), nil
+}
+
+// verifyAgentCommitsAtHead makes sure only agent commits sit between the base and HEAD
+func (a *Agent) verifyAgentCommitsAtHead(root string) error {
+	out, err := git.Run(root, "rev-list", "--reverse", a.gitState.baseCommit+"..HEAD")
+	if err != nil {
+		return err
+	}
+
+	onBranch := strings.Fields(out)
+	if len(onBranch) != len(a.gitState.commits) {
+		return fmt.Errorf("HEAD contains commits not made by the agent; squash manually")
+	}
+	for i, hash := range onBranch {
+		if hash != a.gitState.commits[i] {
+			return fmt.Errorf("HEAD contains commits not made by the agent; squash manually")
+		}
+	}
+	return nil
+}
+
+func (a *Agent) resetCommitState() {
+	a.gitState.commits = nil
+	a.gitState.pendingFiles = nil
+	a.gitState.baseCommit = ""
+}
+
+// generateCommitMessage asks the model to describe the staged changes to files
+func (a *Agent) generateCommitMessage(root string, files []string, fallback string) string {
+	diffArgs := append([]string{"diff", "--cached", "--no-color", "--stat", "-p", "--"}, files...)
+	diff, err := git.Run(root, diffArgs...)
+	if err != nil || strings.TrimSpace(diff) == "" {
+		return fallback
+	}
+	if len(diff) > maxCommitDiffChars {
+		diff = diff[:maxCommitDiffChars] + "\n... diff truncated"
+	}
+
+	var request strings.Builder
+	if a.gitState.turnMessage != "" {
+		request.WriteString(fmt.Sprintf("Request: %s\n\n", a.gitState.turnMessage))
+	}
+	request.WriteString("Diff:\n")
+	request.WriteString(diff)
+
+	response, err := a.client.Chat(
+		[]openrouter.Message{
+			{Role: "system", Content: commitMessagePrompt},
+			{Role: "user", Content: request.String()},
+		},
+		nil,
+		200,
+		0.2,
+	)
+	if err != nil || len(response.Choices) == 0 {
+		return fallback
+	}
+
+	message := strings.Trim(strings.TrimSpace(response.Choices[0].Message.Content), "`\"")
+	if message == "" {
+		return fallback
+	}
+	return message
+}
+
+func describeFiles(root string, files []string) string {
+	if len(files) == 1 {
+		if rel, err := filepath.Rel(root, files[0]); err == nil {
+			return rel
+		}
+		return filepath.Base(files[0])
+	}
+	return fmt.Sprintf("%d files", len(files))
+}
+
+func appendUnique(list []string, item string) []string {
+	for _, existing := range list {
+		if existing == item {
+			return list
+		}
+	}
+	return append(list, item)
+}
@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"bytes"
+	ctx "context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/imports"
+)
+
+const (
+	// goVetTimeout bounds the go vet run after an edit
+	goVetTimeout = 60 * time.Second
+	// maxVetLines bounds how much go vet output is added to a tool result
+	maxVetLines = 30
+)
+
+// goEditTools are the tools whose Go files are formatted and checked
+var goEditTools = map[string]bool{
+	"write_file":      true,
+	"edit_file":       true,
+	"replace_content": true,
+}
+
+// checkGoEdit formats a Go file the model just changed, the way goimports
+// does (gofmt plus adding and removing imports), and optionally runs go vet
+// on its package. It returns a note for the tool result, or "".
+func (a *Agent) checkGoEdit(path string) string {
+	if filepath.Ext(path) != ".go" {
+		return ""
+	}
+
+	var notes []string
+	parses := true
+	if a.Config.Agent.GoFormat {
+		var note string
+		note, parses = formatGoFile(path)
+		if note != "" {
+			notes = append(notes, note)
+		}
+	}
+	// go vet would only repeat a syntax error
+	if a.Config.Agent.GoVet && parses {
+		if note := vetGoPackage(filepath.Dir(path)); note != "" {
+			notes = append(notes, note)
+		}
+	}
+	return strings.Join(notes, "\n")
+}
+
+// formatGoFile rewrites path in canonical form and describes what changed.
+// It reports false when the file does not parse.
+func formatGoFile(path string) (string, bool) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return "", true
+	}
+
+	formatted, err := imports.Process(path, src, nil)
+	if err != nil {
+		return fmt.Sprintf("gofmt: the file does not parse, fix it: %v", err), false
+	}
+	if bytes.Equal(src, formatted) {
+		return "", true
+	}
+	if err := os.WriteFile(path, formatted, 0644); err != nil {
+		return fmt.Sprintf("gofmt: failed to write formatted file: %v", err), true
+	}
+
+	changes := []string{fmt.Sprintf("%d lines reformatted", changedLines(src, formatted))}
+	before, after := importPaths(src), importPaths(formatted)
+	if added := missingFrom(after, before); len(added) > 0 {
+		changes = append(changes, "added imports "+strings.Join(added, ", "))
+	}
+	if removed := missingFrom(before, after); len(removed) > 0 {
+		changes = append(changes, "removed unused imports "+strings.Join(removed, ", "))
+	}
+	return fmt.Sprintf("gofmt: %s was reformatted (%s); re-read it before editing by line number", path, strings.Join(changes, "; ")), true
+}
+
+// vetGoPackage runs go vet on the package in dir and returns its complaints
+func vetGoPackage(dir string) string {
+	timeout, cancel := ctx.WithTimeout(ctx.Background(), goVetTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(timeout, "go", "vet", ".")
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return ""
+	}
+	if timeout.Err() != nil {
+		return fmt.Sprintf("go vet: timed out after %s", goVetTimeout)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) > maxVetLines {
+		lines = append(lines[:maxVetLines], fmt.Sprintf("... %d more lines", len(lines)-maxVetLines))
+	}
+	return "go vet reported problems:\n" + strings.Join(lines, "\n")
+}
+
+// changedLines counts the lines of after that do not appear in before
+func changedLines(before, after []byte) int {
+	seen := make(map[string]int)
+	for _, line := range strings.Split(string(before), "\n") {
+		seen[line]++
+	}
+	changed := 0
+	for _, line := range strings.Split(string(after), "\n") {
+		if seen[line] > 0 {
+			seen[line]--
+		} else {
+			changed++
+		}
+	}
+	return changed
+}
+
+// importPaths lists the import paths of a Go source file
+func importPaths(src []byte) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, spec := range file.Imports {
+		if path, err := strconv.Unquote(spec.Path.Value); err == nil {
+			paths = append(paths, strconv.Quote(path))
+		}
+	}
+	return paths
+}
+
+// missingFrom returns the entries of list that are not in other
+func missingFrom(list, other []string) []string {
+	present := make(map[string]bool, len(other))
+	for _, entry := range other {
+		present[entry] = true
+	}
+	var missing []string
+	for _, entry := range list {
+		if !present[entry] {
+			missing = append(missing, entry)
+		}
+	}
+	return missing
+}
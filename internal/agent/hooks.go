@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/ttli3/go-coding-agent/internal/config"
+	"github.com/ttli3/go-coding-agent/internal/hooks"
+	"github.com/ttli3/go-coding-agent/internal/tools"
+)
+
+// loadHooks builds the hook runner from the configuration and runs the
+// session start hooks
+func (a *Agent) loadHooks() {
+	convert := func(list []config.HookConfig) []hooks.Hook {
+		converted := make([]hooks.Hook, 0, len(list))
+		for _, hook := range list {
+			converted = append(converted, hooks.Hook{
+				Matcher: hook.Matcher,
+				Command: hook.Command,
+				Timeout: time.Duration(hook.Timeout) * time.Second,
+			})
+		}
+		return converted
+	}
+
+	cfg := a.Config.Hooks
+	runner, err := hooks.NewRunner(map[hooks.Event][]hooks.Hook{
+		hooks.SessionStart:     convert(cfg.SessionStart),
+		hooks.UserPromptSubmit: convert(cfg.UserPromptSubmit),
+		hooks.PreToolUse:       convert(cfg.PreToolUse),
+		hooks.PostToolUse:      convert(cfg.PostToolUse),
+		hooks.TurnEnd:          convert(cfg.TurnEnd),
+	})
+	if err != nil {
+		color.New(color.FgRed).Printf("[HOOK] Hooks disabled: %v\n", err)
+		return
+	}
+	a.hooks = runner
+
+	if result := a.runHooks(a.hookInput(hooks.SessionStart)); len(result.Messages) > 0 {
+		a.sessionHookContext = strings.Join(result.Messages, "\n")
+	}
+}
+
+// hookInput describes event in the current session
+func (a *Agent) hookInput(event hooks.Event) hooks.Input {
+	return hooks.Input{
+		Event:       event,
+		SessionID:   a.sessionContext.SessionID,
+		ProjectRoot: a.projectRoot(),
+		WorkingDir:  a.sessionContext.WorkingDir,
+	}
+}
+
+// runHooks runs the hooks for input.Event, reporting hooks that failed
+func (a *Agent) runHooks(input hooks.Input) hooks.Result {
+	if !a.hooks.Has(input.Event) {
+		return hooks.Result{}
+	}
+
+	result := a.hooks.Run(input)
+	reportHookErrors(result)
+	return result
+}
+
+// runToolHooks runs the hooks for a tool call, reporting hooks that failed
+// without interrupting the other calls of a parallel group
+func (a *Agent) runToolHooks(exec *toolExecution, input hooks.Input) hooks.Result {
+	if !a.hooks.Has(input.Event) {
+		return hooks.Result{}
+	}
+
+	result := a.hooks.Run(input)
+	exec.exclusive(func() { reportHookErrors(result) })
+	return result
+}
+
+func reportHookErrors(result hooks.Result) {
+	for _, err := range result.Errors {
+		color.New(color.FgYellow).Printf("[HOOK] %s\n", err)
+	}
+}
+
+// executeTool runs a tool call between its pre_tool_use and post_tool_use
+// hooks. Pre hooks can block the call or replace its arguments (exec.args is
+// updated); feedback from hooks is appended to the result the model sees.
+// Approval prompts and hook errors of a parallel group are shown one at a time.
+// Go files changed by the call are formatted before the post hooks run.
+func (a *Agent) executeTool(exec *toolExecution) *tools.ToolResult {
+	if !a.toolPermitted(exec.name) {
+		var refusal string
+		exec.exclusive(func() { refusal = a.requestApproval(exec) })
+		if refusal != "" {
+			return &tools.ToolResult{
+				Name:    exec.name,
+				Error:   refusal,
+				Success: false,
+			}
+		}
+	}
+
+	input := a.hookInput(hooks.PreToolUse)
+	input.ToolName = exec.name
+	input.ToolArgs = exec.args
+	pre := a.runToolHooks(exec, input)
+	if pre.ToolArgs != nil {
+		exec.args = pre.ToolArgs
+	}
+	if pre.Blocked {
+		return &tools.ToolResult{
+			Name:    exec.name,
+			Error:   "blocked by hook: " + pre.Reason,
+			Success: false,
+		}
+	}
+
+	var before worktreeSnapshot
+	if a.tracksChangesOf(exec.name) {
+		before = a.snapshotWorktree()
+	}
+	result := a.toolRegistry.Execute(exec.name, exec.args)
+	if before != nil {
+		exec.changed = a.changedSince(before)
+	}
+	if path, ok := exec.args["path"].(string); ok && result.Success && goEditTools[exec.name] {
+		if note := a.checkGoEdit(path); note != "" {
+			result.Result += "\n\n" + note
+		}
+	}
+
+	input = a.hookInput(hooks.PostToolUse)
+	input.ToolName = exec.name
+	input.ToolArgs = exec.args
+	input.ToolResult = result.Result
+	input.ToolError = result.Error
+	post := a.runToolHooks(exec, input)
+
+	feedback := append(append([]string{}, pre.Messages...), post.Messages...)
+	if post.Blocked {
+		feedback = append(feedback, post.Reason)
+	}
+	if len(feedback) > 0 {
+		note := "\n\nHook feedback:\n" + strings.Join(feedback, "\n")
+		if result.Success {
+			result.Result += note
+		} else {
+			result.Error += note
+		}
+	}
+	return result
+}
+
+// runTurnEndHooks runs the turn_end hooks with the final response and keeps
+// what they report as context for the next turn
+func (a *Agent) runTurnEndHooks(response string) {
+	input := a.hookInput(hooks.TurnEnd)
+	input.Response = response
+	result := a.runHooks(input)
+
+	notes := result.Messages
+	if result.Blocked {
+		notes = append(notes, result.Reason)
+	}
+	if len(notes) > 0 {
+		a.AddMessage("system", "Notes from turn_end hooks:\n"+strings.Join(notes, "\n"))
+	}
+}
@@ -0,0 +1,248 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// instructionFileName is the file read for project conventions
+	instructionFileName = "AGENTS.md"
+	// maxInstructionFileSize keeps a runaway instruction file from eating the context
+	maxInstructionFileSize = 32 * 1024
+)
+
+// instructionFile is one loaded AGENTS.md
+type instructionFile struct {
+	path    string
+	scope   string // "user-global", "project root" or the directory it applies to
+	content string
+}
+
+// instructionState tracks which instruction files have been loaded
+type instructionState struct {
+	files  []instructionFile
+	loaded map[string]bool
+}
+
+// GlobalInstructionPath is the user-wide instruction file applied to every project
+func (a *Agent) GlobalInstructionPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".agent_go", instructionFileName)
+}
+
+// ProjectInstructionPath is the instruction file at the project root
+func (a *Agent) ProjectInstructionPath() string {
+	return filepath.Join(a.projectRoot(), instructionFileName)
+}
+
+// loadInstructions reads the user-global file, the project root file and any
+// files in directories between the project root and the working directory
+func (a *Agent) loadInstructions() {
+	a.instructions = instructionState{loaded: make(map[string]bool)}
+
+	if path := a.GlobalInstructionPath(); path != "" {
+		a.loadInstructionFile(path, "user-global")
+	}
+	a.loadInstructionFile(a.ProjectInstructionPath(), "project root")
+	a.discoverInstructions(a.sessionContext.WorkingDir)
+}
+
+// loadInstructionFile reads one instruction file, reporting whether it was newly loaded
+func (a *Agent) loadInstructionFile(path, scope string) bool {
+	if a.instructions.loaded[path] {
+		return false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	a.instructions.loaded[path] = true
+
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		return false
+	}
+	if len(content) > maxInstructionFileSize {
+		content = content[:maxInstructionFileSize] + "\n... (truncated)"
+	}
+
+	a.instructions.files = append(a.instructions.files, instructionFile{path: path, scope: scope, content: content})
+	return true
+}
+
+// discoverInstructions loads instruction files in dir and its parents up to the
+// project root, outermost first, returning the ones that were newly loaded
+func (a *Agent) discoverInstructions(dir string) []instructionFile {
+	root := a.projectRoot()
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+
+	var dirs []string
+	for d := dir; d != root && d != filepath.Dir(d); d = filepath.Dir(d) {
+		dirs = append([]string{d}, dirs...)
+	}
+
+	var found []instructionFile
+	for _, d := range dirs {
+		scope, _ := filepath.Rel(root, d)
+		if a.loadInstructionFile(filepath.Join(d, instructionFileName), filepath.ToSlash(scope)+"/") {
+			found = append(found, a.instructions.files[len(a.instructions.files)-1])
+		}
+	}
+	return found
+}
+
+// loadNestedInstructions loads instruction files that apply to a file the
+// agent touched, reporting whether any were new
+func (a *Agent) loadNestedInstructions(path string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	return len(a.discoverInstructions(filepath.Dir(absPath))) > 0
+}
+
+// instructionsContext formats every loaded instruction file for the system prompt
+func (a *Agent) instructionsContext() string {
+	if len(a.instructions.files) == 0 {
+		return ""
+	}
+
+	var info strings.Builder
+	info.WriteString("PROJECT INSTRUCTIONS (follow these; more specific files take precedence):\n")
+	for _, file := range a.instructions.files {
+		info.WriteString(fmt.Sprintf("--- %s (%s) ---\n%s\n", a.displayInstructionPath(file.path), file.scope, file.content))
+	}
+	return info.String()
+}
+
+// displayInstructionPath shortens paths inside the project or home directory
+func (a *Agent) displayInstructionPath(path string) string {
+	if rel, err := filepath.Rel(a.projectRoot(), path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return "~/" + filepath.ToSlash(rel)
+		}
+	}
+	return path
+}
+
+// InstructionSummary describes the loaded instruction files for /memory
+func (a *Agent) InstructionSummary() string {
+	var summary strings.Builder
+	if len(a.instructions.files) == 0 {
+		summary.WriteString("No instruction files loaded.\n")
+	} else {
+		summary.WriteString(fmt.Sprintf("Loaded instruction files (%d):\n", len(a.instructions.files)))
+		for i, file := range a.instructions.files {
+			summary.WriteString(fmt.Sprintf("  %d. %s (%s, %d lines)\n", i+1, a.displayInstructionPath(file.path), file.scope, strings.Count(file.content, "\n")+1))
+		}
+	}
+
+	summary.WriteString("\nLocations:\n")
+	for _, path := range []string{a.GlobalInstructionPath(), a.ProjectInstructionPath()} {
+		state := "missing"
+		if _, err := os.Stat(path); err == nil {
+			state = "exists"
+		}
+		summary.WriteString(fmt.Sprintf("  %s (%s)\n", path, state))
+	}
+	summary.WriteString(fmt.Sprintf("Nested %s files are loaded when the agent touches files in their directories.", instructionFileName))
+	return summary.String()
+}
+
+// InstructionContents returns the full text of every loaded instruction file
+func (a *Agent) InstructionContents() string {
+	if len(a.instructions.files) == 0 {
+		return "No instruction files loaded."
+	}
+
+	var out strings.Builder
+	for _, file := range a.instructions.files {
+		out.WriteString(fmt.Sprintf("=== %s (%s) ===\n%s\n\n", a.displayInstructionPath(file.path), file.scope, file.content))
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// AddInstruction appends a bullet to the project instruction file, creating it if needed
+func (a *Agent) AddInstruction(text string) (string, error) {
+	path := a.ProjectInstructionPath()
+
+	var prefix string
+	if data, err := os.ReadFile(path); err == nil && len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		prefix = "\n"
+	} else if os.IsNotExist(err) {
+		prefix = "# Agent Instructions\n\n"
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(fmt.Sprintf("%s- %s\n", prefix, text)); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	a.ReloadInstructions()
+	return fmt.Sprintf("Added to %s", path), nil
+}
+
+// ReloadInstructions re-reads instruction files and updates the system prompt
+// of the current conversation
+func (a *Agent) ReloadInstructions() {
+	touched := a.instructions.loaded
+	a.loadInstructions()
+
+	// Keep nested files that were discovered during the session
+	for path := range touched {
+		if !a.instructions.loaded[path] {
+			a.discoverInstructions(filepath.Dir(path))
+		}
+	}
+
+	a.contextWindow.UpdateSystemMessage(a.GetSystemPrompt())
+}
+
+// InitInstructions has the model analyze the repository and write or improve
+// the project instruction file
+func (a *Agent) InitInstructions() (string, error) {
+	path := a.ProjectInstructionPath()
+
+	action := fmt.Sprintf("Create %s", path)
+	if _, err := os.Stat(path); err == nil {
+		action = fmt.Sprintf("Improve the existing %s (read it first and keep anything still accurate)", path)
+	}
+
+	prompt := fmt.Sprintf(`%s: an instruction file that tells AI coding agents how to work in this repository.
+
+First analyze the repository: read the README and build files, look at the directory layout and a few representative source files, and check for existing lint, test and CI configuration.
+
+Then write the file with these sections, keeping it under about 100 lines and specific to this repository:
+- Build, test and lint commands (including how to run a single test)
+- Architecture overview: the main packages/modules and how they fit together
+- Code conventions: naming, error handling, formatting and testing patterns actually used here
+- Gotchas: anything non-obvious a new contributor would get wrong
+
+Do not invent commands or conventions you did not see in the repository.`, action)
+
+	response, err := a.ProcessMessage(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	a.ReloadInstructions()
+	return response, nil
+}
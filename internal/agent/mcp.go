@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/ttli3/go-coding-agent/internal/mcp"
+)
+
+// mcpServer is a configured MCP server and the outcome of connecting to it
+type mcpServer struct {
+	config mcp.ServerConfig
+	client *mcp.Client
+	tools  []*mcp.RemoteTool // the tools registered
+	err    error
+}
+
+// loadMCPServers connects to the configured servers concurrently and
+// registers their tools. Servers that fail are reported and skipped.
+func (a *Agent) loadMCPServers() {
+	cfg := a.Config.MCP
+	dir := a.projectRoot()
+	var servers []*mcpServer
+	for _, server := range cfg.Servers {
+		if server.Disabled {
+			continue
+		}
+		servers = append(servers, &mcpServer{config: mcp.ServerConfig{
+			Name:    server.Name,
+			Command: server.Command,
+			Args:    server.Args,
+			Env:     server.Env,
+			Dir:     dir,
+			URL:     server.URL,
+			Headers: server.Headers,
+			Timeout: time.Duration(cfg.Timeout) * time.Second,
+
+			Trusted:       server.Trusted,
+			ReadOnlyTools: server.ReadOnlyTools,
+		}})
+	}
+	if len(servers) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server *mcpServer) {
+			defer wg.Done()
+			server.client, server.err = mcp.Connect(server.config)
+		}(server)
+	}
+	wg.Wait()
+
+	for _, server := range servers {
+		if server.err != nil {
+			color.New(color.FgRed).Printf("[MCP] %s: %v\n", server.config.Name, server.err)
+			continue
+		}
+		for _, tool := range server.client.Tools() {
+			remote := mcp.NewTool(server.client, tool)
+			if _, exists := a.toolRegistry.Get(remote.Name()); exists {
+				color.New(color.FgYellow).Printf("[MCP] %s: skipping %s, a tool with that name exists\n", server.config.Name, tool.Name)
+				continue
+			}
+			a.toolRegistry.Register(remote)
+			server.tools = append(server.tools, remote)
+		}
+		color.New(color.FgHiBlack).Printf("[MCP] %s: %d tools\n", server.config.Name, len(server.tools))
+	}
+	a.mcpServers = servers
+}
+
+// MCPServers reports the configured MCP servers and their tools
+func (a *Agent) MCPServers() []mcp.Status {
+	statuses := make([]mcp.Status, 0, len(a.mcpServers))
+	for _, server := range a.mcpServers {
+		status := mcp.Status{
+			Name:      server.config.Name,
+			Transport: server.config.Transport(),
+			Target:    server.config.Target(),
+		}
+		if server.err != nil {
+			status.Error = server.err.Error()
+		} else {
+			status.Server = server.client.ServerInfo()
+		}
+		for _, tool := range server.tools {
+			status.Tools = append(status.Tools, mcp.ToolStatus{Name: tool.Name(), Description: tool.Remote().Description, ReadOnly: tool.ReadOnly()})
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Close stops the MCP servers started for this agent
+func (a *Agent) Close() {
+	for _, server := range a.mcpServers {
+		if server.client != nil {
+			server.client.Close()
+		}
+	}
+	a.mcpServers = nil
+}
@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/ttli3/go-coding-agent/internal/tools"
+)
+
+// Permission modes for running without anyone to confirm tool calls
+const (
+	PermissionReadOnly = "read-only" // only tools that don't change anything
+	PermissionEdit     = "edit"      // also edit files, without running any code
+	PermissionAll      = "all"       // every tool; commands run without confirmation
+)
+
+// editTools are the tools allowed in edit mode besides the read-only ones.
+// run_tests is left out: together with file edits it would run any code the
+// model writes in a test.
+var editTools = map[string]bool{
+	"write_file":      true,
+	"edit_file":       true,
+	"replace_content": true,
+	"todo_write":      true,
+}
+
+// ToolPolicy decides which tools may run. Allowed and Disallowed name tools
+// that are permitted or refused regardless of the mode.
+type ToolPolicy struct {
+	Mode       string
+	Allowed    []string
+	Disallowed []string
+}
+
+// Validate checks the mode
+func (p *ToolPolicy) Validate() error {
+	switch p.Mode {
+	case PermissionReadOnly, PermissionEdit, PermissionAll:
+		return nil
+	}
+	return fmt.Errorf("unknown permission mode %q (use %s, %s or %s)", p.Mode, PermissionReadOnly, PermissionEdit, PermissionAll)
+}
+
+// Permits reports whether tool may run under the policy
+func (p *ToolPolicy) Permits(tool tools.Tool) bool {
+	name := tool.Name()
+	for _, denied := range p.Disallowed {
+		if denied == name {
+			return false
+		}
+	}
+	for _, allowed := range p.Allowed {
+		if allowed == name {
+			return true
+		}
+	}
+
+	switch p.Mode {
+	case PermissionAll:
+		return true
+	case PermissionEdit:
+		return tools.IsReadOnly(tool) || editTools[name]
+	default:
+		return tools.IsReadOnly(tool)
+	}
+}
+
+// SetToolPolicy restricts the tools offered to the model and executed, or
+// lifts the restriction when policy is nil
+func (a *Agent) SetToolPolicy(policy *ToolPolicy) {
+	a.policy = policy
+}
+
+// toolPermitted reports whether the policy lets the named tool run
+func (a *Agent) toolPermitted(name string) bool {
+	if a.policy == nil {
+		return true
+	}
+	tool, ok := a.toolRegistry.Get(name)
+	return !ok || a.policy.Permits(tool)
+}
+
+// Approver is asked whether a tool call the policy doesn't permit may run
+// anyway. It may block until someone answers; reason explains a refusal.
+type Approver func(request Event) (approved bool, reason string)
+
+// SetApprover lets tool calls outside the policy run when approver agrees,
+// instead of refusing them, and offers every tool to the model; nil
+// restores refusal
+func (a *Agent) SetApprover(approver Approver) {
+	a.approver = approver
+}
+
+// requestApproval asks the approver about a call the policy doesn't permit
+// and returns why it was refused, or "" when it may run
+func (a *Agent) requestApproval(exec *toolExecution) string {
+	if a.approver == nil {
+		return fmt.Sprintf("%s is not permitted in %s permission mode", exec.name, a.policy.Mode)
+	}
+	approved, reason := a.approver(Event{Type: EventApproval, ID: exec.id, Tool: exec.name, Args: exec.args})
+	if approved {
+		return ""
+	}
+	refusal := fmt.Sprintf("the user denied %s", exec.name)
+	if reason != "" {
+		refusal += ": " + reason
+	}
+	return refusal
+}
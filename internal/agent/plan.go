@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PlanExecutionPrompt is sent on the user's behalf once a plan is approved
+const PlanExecutionPrompt = "The plan is approved. Carry it out step by step."
+
+// planStepPattern matches a numbered plan step such as "1. ..." or "2) ..."
+var planStepPattern = regexp.MustCompile(`(?m)^\s*(?:\*\*)?\d+[.)]\s+\S`)
+
+// planState tracks plan mode and the plan proposed in it
+type planState struct {
+	active   bool
+	proposed string // latest numbered plan from the model, awaiting review
+}
+
+// InPlanMode reports whether the agent is restricted to read-only investigation
+func (a *Agent) InPlanMode() bool {
+	return a.plan.active
+}
+
+// SetPlanMode switches plan mode on or off. In plan mode only read-only tools
+// are offered and the model is asked to finish with a numbered plan.
+func (a *Agent) SetPlanMode(active bool) string {
+	a.plan.active = active
+	a.plan.proposed = ""
+	a.toolRegistry.SetReadOnly(active)
+
+	if active {
+		return "Plan mode on: the agent can only read and search. Describe the task, and review the plan it proposes before anything is changed"
+	}
+	return "Plan mode off: all tools are available"
+}
+
+// ProposedPlan returns the plan awaiting review, if any
+func (a *Agent) ProposedPlan() string {
+	return a.plan.proposed
+}
+
+// PlanStatus describes plan mode and any plan awaiting review
+func (a *Agent) PlanStatus() string {
+	if !a.plan.active {
+		return "Plan mode is off (use /plan to investigate read-only and review a plan first)"
+	}
+	if a.plan.proposed == "" {
+		return "Plan mode is on; no plan proposed yet"
+	}
+	return fmt.Sprintf("Plan mode is on; proposed plan (%d steps):\n%s", countPlanSteps(a.plan.proposed), a.plan.proposed)
+}
+
+// ApprovePlan leaves plan mode with plan (or the proposed plan when empty)
+// pinned in the conversation so it stays in context while executing
+func (a *Agent) ApprovePlan(plan string) (string, error) {
+	plan = strings.TrimSpace(plan)
+	if plan == "" {
+		plan = a.plan.proposed
+	}
+	if plan == "" {
+		return "", fmt.Errorf("no plan to approve; ask the agent for a numbered plan first")
+	}
+
+	a.SetPlanMode(false)
+	a.contextWindow.AddMessage("user", "APPROVED PLAN (follow it; ask before deviating from it):\n"+plan, true)
+
+	result := fmt.Sprintf("Plan approved (%d steps); all tools are available", countPlanSteps(plan))
+	if last := a.contextWindow.Messages[len(a.contextWindow.Messages)-1]; !last.Pinned {
+		result += ". The plan did not fit in the pinned budget (agent.pinned_tokens), so it may be trimmed from context"
+	}
+	return result, nil
+}
+
+// RejectPlan discards the proposed plan and stays in plan mode
+func (a *Agent) RejectPlan() string {
+	a.plan.proposed = ""
+	return "Plan rejected; still in plan mode. Tell the agent what to change"
+}
+
+// capturePlan records the model's latest reply as the proposed plan when it
+// contains numbered steps
+func (a *Agent) capturePlan() {
+	if !a.plan.active {
+		return
+	}
+
+	messages := a.contextWindow.Messages
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "assistant" || strings.TrimSpace(messages[i].Content) == "" {
+			continue
+		}
+		if countPlanSteps(messages[i].Content) >= 2 {
+			a.plan.proposed = strings.TrimSpace(messages[i].Content)
+		}
+		return
+	}
+}
+
+// planModeContext tells the model about plan mode on every request
+func (a *Agent) planModeContext() string {
+	if !a.plan.active {
+		return ""
+	}
+	return `PLAN MODE: You can only use read-only tools; do not try to change files or run commands.
+Investigate what you need, then reply with a numbered plan (1., 2., ...) listing the files to change and how, and how the result will be verified.
+The user will approve, edit or reject the plan before anything is executed.`
+}
+
+func countPlanSteps(plan string) int {
+	return len(planStepPattern.FindAllString(plan, -1))
+}
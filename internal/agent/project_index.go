@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"path/filepath"
+
+	"github.com/ttli3/go-coding-agent/internal/project"
+	"github.com/ttli3/go-coding-agent/internal/repomap"
+	"github.com/ttli3/go-coding-agent/internal/tools"
+)
+
+// indexProject (re)builds the per-project indexes for the current project
+// root: the repository map and the search_codebase index
+func (a *Agent) indexProject() {
+	a.buildRepoMap()
+	a.registerCodebaseSearch()
+}
+
+// projectRoot is the directory the project indexes cover
+func (a *Agent) projectRoot() string {
+	if a.sessionContext.ProjectRoot != "" {
+		return a.sessionContext.ProjectRoot
+	}
+	return a.sessionContext.WorkingDir
+}
+
+// ProjectLayout returns the detected languages and sub-projects, detecting
+// them first if that hasn't happened yet
+func (a *Agent) ProjectLayout() *project.Layout {
+	if a.sessionContext.Layout == nil {
+		a.sessionContext.DetectProjectType()
+	}
+	return a.sessionContext.Layout
+}
+
+// agentFilePath returns a path inside the project's agent directory, or ""
+// when the directory can't be created
+func (a *Agent) agentFilePath(name string) string {
+	dir, err := a.sessionContext.AgentDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, name)
+}
+
+// buildRepoMap indexes the project for the system prompt's repository map.
+// Unchanged files are reused from the cache in the project's agent directory.
+func (a *Agent) buildRepoMap() {
+	a.repoMap = nil
+	if a.Config.Agent.RepoMapTokens <= 0 {
+		return
+	}
+
+	if m, err := repomap.Build(a.projectRoot(), a.agentFilePath("repomap.json")); err == nil {
+		a.repoMap = m
+	}
+}
+
+// repoMapContext renders the repository map ranked for the focused files and
+// the current task, falling back to the user's request when no task is set
+func (a *Agent) repoMapContext(request string) string {
+	task := a.sessionContext.CurrentTask
+	if task == "" {
+		task = request
+	}
+	return a.repoMap.Render(a.Config.Agent.RepoMapTokens, a.sessionContext.FocusedFiles, task)
+}
+
+// registerCodebaseSearch registers search_codebase for the current project and
+// refreshes its index in the background so only changed files are re-read
+func (a *Agent) registerCodebaseSearch() {
+	indexPath := a.agentFilePath("codeindex.gob")
+	if indexPath == "" {
+		return
+	}
+
+	searchTool := tools.NewSearchCodebaseTool(a.projectRoot(), indexPath)
+	a.toolRegistry.Register(searchTool)
+	go searchTool.Refresh()
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/ttli3/go-coding-agent/internal/context"
+	"github.com/ttli3/go-coding-agent/internal/tools"
+)
+
+// registerTodoTools gives the model a checklist stored in the session
+func (a *Agent) registerTodoTools() {
+	a.toolRegistry.Register(tools.NewTodoWriteTool(a))
+	a.toolRegistry.Register(tools.NewTodoReadTool(a))
+}
+
+// UpdateTodos replaces the model's checklist. Once every item is done, the
+// current task (if any) is completed with the files changed while it was set.
+func (a *Agent) UpdateTodos(items []context.TodoItem) (string, error) {
+	if err := a.sessionContext.SetTodos(items); err != nil {
+		return "", err
+	}
+
+	list := a.sessionContext.FormatTodos()
+	if a.sessionContext.TodosComplete() && a.sessionContext.CurrentTask != "" {
+		task := a.sessionContext.CurrentTask
+		a.sessionContext.CompleteCurrentTask(nil)
+		list += fmt.Sprintf("\nAll items are done; completed task: %s", task)
+	}
+	return list, nil
+}
+
+// TodoList returns the model's checklist
+func (a *Agent) TodoList() string {
+	return a.sessionContext.FormatTodos()
+}
+
+// todoContext is the unfinished checklist, sent with every request so it
+// survives compaction of the todo tool results
+func (a *Agent) todoContext() string {
+	if len(a.sessionContext.Todos) == 0 || a.sessionContext.TodosComplete() {
+		return ""
+	}
+	return "CURRENT TODO LIST (keep it up to date with todo_write):\n" + a.sessionContext.FormatTodos()
+}
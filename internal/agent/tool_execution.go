@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ttli3/go-coding-agent/internal/openrouter"
+	"github.com/ttli3/go-coding-agent/internal/tools"
+	"github.com/ttli3/go-coding-agent/internal/ui"
+)
+
+// toolExecution is one tool call from the model and its outcome
+type toolExecution struct {
+	id       string
+	name     string
+	args     map[string]interface{}
+	parseErr error // arguments could not be decoded, so the tool never ran
+	result   *tools.ToolResult
+	changed  []string // files the tool changed without naming them
+
+	// serialize runs output and prompts of a call in a parallel group one
+	// at a time; nil when the call runs alone
+	serialize func(func())
+}
+
+// exclusive runs fn without overlapping the output and prompts of the other
+// calls in the group
+func (exec *toolExecution) exclusive(fn func()) {
+	if exec.serialize == nil {
+		fn()
+		return
+	}
+	exec.serialize(fn)
+}
+
+// runToolCalls executes the calls of one model response. Consecutive
+// read-only calls run concurrently, up to agent.parallel_tools at a time;
+// every other call runs alone, in order, so it sees the effects of the calls
+// before it. handle is called for every call in order, on the calling
+// goroutine, as soon as the call's group has finished.
+func (a *Agent) runToolCalls(toolCalls []openrouter.ToolCall, display *ui.ToolExecutionDisplay, handle func(*toolExecution)) {
+	executions := make([]*toolExecution, len(toolCalls))
+	for i, toolCall := range toolCalls {
+		args, err := parseToolArguments(toolCall.Function.Arguments)
+		executions[i] = &toolExecution{id: toolCall.ID, name: toolCall.Function.Name, args: args, parseErr: err}
+		a.emit(Event{Type: EventToolCall, ID: toolCall.ID, Tool: toolCall.Function.Name, Args: args})
+	}
+
+	for start := 0; start < len(executions); {
+		end := start + 1
+		if a.canRunInParallel(executions[start]) {
+			for end < len(executions) && a.canRunInParallel(executions[end]) {
+				end++
+			}
+		}
+
+		if end-start > 1 {
+			a.runParallel(executions[start:end], display)
+		} else {
+			a.runSequential(executions[start], display)
+		}
+		for _, exec := range executions[start:end] {
+			a.emitToolResult(exec)
+			handle(exec)
+		}
+		start = end
+	}
+}
+
+// canRunInParallel reports whether a call may overlap with its neighbours
+func (a *Agent) canRunInParallel(exec *toolExecution) bool {
+	if exec.parseErr != nil || a.Config.Agent.ParallelTools <= 1 {
+		return false
+	}
+	tool, ok := a.toolRegistry.Get(exec.name)
+	return ok && tools.IsReadOnly(tool)
+}
+
+func (a *Agent) runSequential(exec *toolExecution, display *ui.ToolExecutionDisplay) {
+	if exec.parseErr != nil {
+		display.StartTool(exec.name, make(map[string]interface{}))
+		display.FinishTool(false, "", exec.parseErr)
+		return
+	}
+
+	display.StartTool(exec.name, exec.args)
+	exec.result = a.executeTool(exec)
+	display.FinishTool(exec.result.Success, exec.result.Result, resultError(exec.result))
+}
+
+func (a *Agent) runParallel(group []*toolExecution, display *ui.ToolExecutionDisplay) {
+	names := make([]string, len(group))
+	for i, exec := range group {
+		names[i] = exec.name
+	}
+	display.StartParallel(names)
+
+	slots := make(chan struct{}, a.Config.Agent.ParallelTools)
+	var wg sync.WaitGroup
+	for _, exec := range group {
+		exec.serialize = display.Serialize
+		wg.Add(1)
+		go func(exec *toolExecution) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			start := time.Now()
+			exec.result = a.executeTool(exec)
+			display.FinishParallelTool(exec.name, exec.args, time.Since(start), exec.result.Success, exec.result.Result, resultError(exec.result))
+		}(exec)
+	}
+	wg.Wait()
+	display.FinishParallel()
+}
+
+// emitToolResult reports the outcome of a call to the event handler
+func (a *Agent) emitToolResult(exec *toolExecution) {
+	event := Event{Type: EventToolResult, ID: exec.id, Tool: exec.name}
+	switch {
+	case exec.parseErr != nil:
+		event.Content, event.IsError = exec.parseErr.Error(), true
+	case exec.result.Success:
+		event.Content = exec.result.Result
+	default:
+		event.Content, event.IsError = exec.result.Error, true
+	}
+	a.emit(event)
+}
+
+// resultError returns a failed result's error for display
+func resultError(result *tools.ToolResult) error {
+	if !result.Success && result.Error != "" {
+		return fmt.Errorf("%s", result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package agent
+
+import (
+	ctx "context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/ttli3/go-coding-agent/internal/openrouter"
+	"github.com/ttli3/go-coding-agent/internal/project"
+	"github.com/ttli3/go-coding-agent/internal/testrun"
+)
+
+// maxCheckOutputLines bounds the output of a failed check sent to the model
+const maxCheckOutputLines = 40
+
+// verifyState tracks the files changed in the current turn and the checks
+// run on them before the turn ends
+type verifyState struct {
+	enabled   bool
+	turnFiles []string      // absolute paths changed during the current turn
+	rounds    int           // times failures were sent back this turn
+	results   []checkResult // outcome of the most recent run
+}
+
+// verifyCheck is one command to run
+type verifyCheck struct {
+	step    string // build, test, lint or "" for configured commands
+	command string
+	dir     string // absolute
+}
+
+// checkResult is the outcome of one check
+type checkResult struct {
+	command string
+	dir     string // relative to the project root
+	passed  bool
+	output  string // the first line summarizes the outcome
+}
+
+// IsVerifyEnabled reports whether checks run before a turn that changed files ends
+func (a *Agent) IsVerifyEnabled() bool {
+	return a.verify.enabled
+}
+
+// SetVerify turns verification on or off
+func (a *Agent) SetVerify(enabled bool) {
+	a.verify.enabled = enabled
+}
+
+// VerifyChecks describes the checks that would run for the files changed
+// in the current turn, or for the whole project when none were changed
+func (a *Agent) VerifyChecks() []string {
+	var descriptions []string
+	for _, check := range a.verifyChecks() {
+		descriptions = append(descriptions, fmt.Sprintf("%s (in %s)", check.command, a.displayPath(check.dir)))
+	}
+	return descriptions
+}
+
+// VerificationFailed reports whether checks still failed when the last
+// turn ended
+func (a *Agent) VerificationFailed() bool {
+	for _, result := range a.verify.results {
+		if !result.passed {
+			return true
+		}
+	}
+	return false
+}
+
+// startVerifyTurn forgets the previous turn's changes and results
+func (a *Agent) startVerifyTurn() {
+	a.verify.turnFiles = nil
+	a.verify.rounds = 0
+	a.verify.results = nil
+}
+
+// recordTurnFile notes a file changed during the current turn
+func (a *Agent) recordTurnFile(path string) {
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	for _, f := range a.verify.turnFiles {
+		if f == path {
+			return
+		}
+	}
+	a.verify.turnFiles = append(a.verify.turnFiles, path)
+}
+
+// verifyTurn runs the checks once the model has finished a turn that changed
+// files. When a check fails and rounds remain, the failures are sent back to
+// the model; its reply is added to responses and its tool calls returned so
+// the turn continues.
+func (a *Agent) verifyTurn(responses *[]string) []openrouter.ToolCall {
+	if !a.verify.enabled || len(a.verify.turnFiles) == 0 {
+		return nil
+	}
+
+	a.verify.results = a.runChecks()
+	var failed []checkResult
+	for _, result := range a.verify.results {
+		if !result.passed {
+			failed = append(failed, result)
+		}
+	}
+	if len(failed) == 0 || a.verify.rounds >= a.Config.Verify.MaxRounds {
+		return nil
+	}
+	a.verify.rounds++
+
+	var feedback strings.Builder
+	feedback.WriteString(fmt.Sprintf("VERIFICATION FAILED after your changes (round %d of %d). Fix the problems below, then give your final summary.\n", a.verify.rounds, a.Config.Verify.MaxRounds))
+	for _, result := range failed {
+		feedback.WriteString(fmt.Sprintf("\n$ %s (in %s)\n%s\n", result.command, result.dir, limitLines(result.output, maxCheckOutputLines)))
+	}
+	a.AddMessage("user", feedback.String())
+
+	response, err := a.requestCompletion()
+	if err != nil {
+		*responses = append(*responses, fmt.Sprintf("\nFailed to get follow-up response: %v", err))
+		return nil
+	}
+	if len(response.Choices) == 0 {
+		return nil
+	}
+
+	message := response.Choices[0].Message
+	a.AddMessage("assistant", message.Content)
+	*responses = append(*responses, message.Content)
+	return message.ToolCalls
+}
+
+// verifySummary reports the last checks for the final response, or ""
+func (a *Agent) verifySummary() string {
+	if len(a.verify.results) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString("\nVerification:\n")
+	passed := true
+	for _, result := range a.verify.results {
+		status := "[PASS]"
+		if !result.passed {
+			status = "[FAIL]"
+			passed = false
+		}
+		out.WriteString(fmt.Sprintf("%s %s (in %s)", status, result.command, result.dir))
+		if summary := strings.SplitN(result.output, "\n", 2)[0]; summary != "" {
+			out.WriteString(": " + summary)
+		}
+		out.WriteString("\n")
+	}
+	if !passed && a.verify.rounds > 0 {
+		out.WriteString(fmt.Sprintf("Checks still fail after %d round(s) of fixes.\n", a.verify.rounds))
+	}
+	return strings.TrimRight(out.String(), "\n")
+}
+
+// verifyChecks lists the checks for the current turn: the configured
+// commands, or the detected commands of every sub-project with changed files
+func (a *Agent) verifyChecks() []verifyCheck {
+	root := a.projectRoot()
+	var checks []verifyCheck
+	if len(a.Config.Verify.Commands) > 0 {
+		for _, command := range a.Config.Verify.Commands {
+			checks = append(checks, verifyCheck{command: command, dir: root})
+		}
+		return checks
+	}
+
+	layout := a.ProjectLayout()
+	if layout == nil {
+		return nil
+	}
+	files := a.verify.turnFiles
+	if len(files) == 0 {
+		files = []string{root}
+	}
+
+	seen := make(map[*project.SubProject]bool)
+	for _, file := range files {
+		proj := layout.ProjectFor(file)
+		if proj == nil || seen[proj] {
+			continue
+		}
+		seen[proj] = true
+
+		dir := filepath.Join(layout.Root, filepath.FromSlash(proj.Path))
+		for _, step := range a.Config.Verify.Steps {
+			var command string
+			switch step {
+			case "build":
+				command = proj.Build
+			case "test":
+				command = proj.Test
+			case "lint":
+				command = proj.Lint
+			}
+			if command != "" {
+				checks = append(checks, verifyCheck{step: step, command: command, dir: dir})
+			}
+		}
+	}
+	return checks
+}
+
+// runChecks runs every check, reporting progress as it goes
+func (a *Agent) runChecks() []checkResult {
+	timeout := time.Duration(a.Config.Verify.Timeout) * time.Second
+	var results []checkResult
+	for _, check := range a.verifyChecks() {
+		dir := a.displayPath(check.dir)
+		color.New(color.FgHiBlack).Printf("[VERIFY] %s (in %s)... ", check.command, dir)
+
+		var result checkResult
+		if check.step == "test" {
+			// The test runner condenses go test output into per-test results
+			result = a.runTestCheck(check, timeout)
+		} else {
+			result = runCheckCommand(check, timeout)
+		}
+		result.dir = dir
+
+		if result.passed {
+			color.New(color.FgGreen).Println("ok")
+		} else {
+			color.New(color.FgRed).Println("failed")
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (a *Agent) runTestCheck(check verifyCheck, timeout time.Duration) checkResult {
+	report, err := testrun.Run(a.ProjectLayout(), testrun.Options{Path: check.dir, Timeout: timeout})
+	if err != nil {
+		return checkResult{command: check.command, output: err.Error()}
+	}
+	return checkResult{command: report.Command, passed: report.Passed, output: report.Summary()}
+}
+
+// runCheckCommand runs a shell command and keeps its output
+func runCheckCommand(check verifyCheck, timeout time.Duration) checkResult {
+	if timeout <= 0 {
+		timeout = testrun.DefaultTimeout
+	}
+	timeoutCtx, cancel := ctx.WithTimeout(ctx.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(timeoutCtx, "sh", "-c", check.command)
+	cmd.Dir = check.dir
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+
+	result := checkResult{command: check.command, passed: err == nil}
+	text := strings.TrimSpace(string(output))
+	switch {
+	case timeoutCtx.Err() != nil:
+		result.output = fmt.Sprintf("timed out after %s\n%s", timeout, text)
+	case err != nil:
+		result.output = fmt.Sprintf("%v\n%s", err, text)
+	default:
+		result.output = text
+	}
+	return result
+}
+
+// limitLines keeps the first n lines of text
+func limitLines(text string, n int) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) <= n {
+		return text
+	}
+	return strings.Join(lines[:n], "\n") + fmt.Sprintf("\n... %d more lines", len(lines)-n)
+}
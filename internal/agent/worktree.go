@@ -0,0 +1,276 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ttli3/go-coding-agent/internal/git"
+)
+
+// worktreeBranchPrefix namespaces branches created for isolated tasks
+const worktreeBranchPrefix = "agent/"
+
+// worktreeState describes an active isolated worktree
+type worktreeState struct {
+	task         string
+	branch       string
+	path         string // worktree checkout
+	originalRoot string // repository the worktree was created from
+	originalDir  string // working directory before switching
+	toolRoot     string // directory tools were confined to before switching, if any
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// InWorktree reports whether the agent is currently working in an isolated worktree
+func (a *Agent) InWorktree() bool {
+	return a.worktree != nil
+}
+
+// WorktreeStatus describes the active worktree, if any
+func (a *Agent) WorktreeStatus() string {
+	if a.worktree == nil {
+		return "Not working in a worktree"
+	}
+
+	status := fmt.Sprintf("Task: %s\nBranch: %s\nWorktree: %s\nOriginal checkout: %s",
+		a.worktree.task, a.worktree.branch, a.worktree.path, a.worktree.originalRoot)
+
+	if out, err := git.Run(a.worktree.path, "status", "--short"); err == nil {
+		changes := len(strings.Split(strings.TrimSpace(out), "\n"))
+		if strings.TrimSpace(out) == "" {
+			changes = 0
+		}
+		status += fmt.Sprintf("\nUncommitted changes: %d files", changes)
+	}
+	return status
+}
+
+// StartWorktree creates a git worktree on a new branch for task and points the
+// session and all tools at it
+func (a *Agent) StartWorktree(task string) (string, error) {
+	if a.worktree != nil {
+		return "", fmt.Errorf("already working in worktree %s; merge or discard it first", a.worktree.path)
+	}
+
+	originalDir := a.sessionContext.WorkingDir
+	root, err := git.TopLevel(originalDir)
+	if err != nil {
+		return "", fmt.Errorf("worktrees require a git repository")
+	}
+
+	slug := worktreeSlug(task)
+	branch := worktreeBranchPrefix + slug
+	if git.BranchExists(root, branch) {
+		slug = fmt.Sprintf("%s-%d", slug, time.Now().Unix())
+		branch = worktreeBranchPrefix + slug
+	}
+	path := filepath.Join(filepath.Dir(root), filepath.Base(root)+".worktrees", slug)
+
+	if _, err := git.Run(root, "worktree", "add", "-b", branch, path, "HEAD"); err != nil {
+		return "", err
+	}
+
+	// Keep the same relative directory inside the new checkout
+	workDir := path
+	if rel, err := filepath.Rel(root, originalDir); err == nil && !strings.HasPrefix(rel, "..") {
+		workDir = filepath.Join(path, rel)
+	}
+	// Move a confined registry along, so tools can't reach the original checkout
+	toolRoot := a.toolRegistry.Root()
+	enterErr := a.toolRegistry.SetRoot(worktreeToolRoot(toolRoot, root, path))
+	if enterErr == nil {
+		if enterErr = os.Chdir(workDir); enterErr != nil {
+			a.toolRegistry.SetRoot(toolRoot)
+		}
+	}
+	if enterErr != nil {
+		git.Run(root, "worktree", "remove", "--force", path)
+		git.Run(root, "branch", "-D", branch)
+		return "", fmt.Errorf("failed to enter worktree: %w", enterErr)
+	}
+
+	a.worktree = &worktreeState{
+		task:         task,
+		branch:       branch,
+		path:         path,
+		originalRoot: root,
+		originalDir:  originalDir,
+		toolRoot:     toolRoot,
+	}
+
+	a.sessionContext.SetWorkingDir(workDir)
+	a.sessionContext.RebaseFiles(root, path)
+	a.indexProject()
+	a.loadInstructions()
+	if task != "" {
+		a.sessionContext.SetCurrentTask(task)
+	}
+	a.resetCommitState()
+
+	return fmt.Sprintf("Working in worktree %s on branch %s\nYour checkout at %s will not be touched", path, branch, root), nil
+}
+
+// FinishWorktree leaves the active worktree. action is "merge" to commit and merge
+// the branch into the original checkout, "discard" to delete it, or "keep" to
+// leave the worktree and branch in place.
+func (a *Agent) FinishWorktree(action string) (string, error) {
+	wt := a.worktree
+	if wt == nil {
+		return "", fmt.Errorf("not working in a worktree")
+	}
+
+	switch action {
+	case "merge":
+		if err := a.commitWorktreeChanges(wt); err != nil {
+			return "", err
+		}
+
+		ahead, err := git.Run(wt.originalRoot, "rev-list", "--count", "HEAD.."+wt.branch)
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(ahead) != "0" {
+			message := fmt.Sprintf("Merge %s", wt.branch)
+			if wt.task != "" {
+				message = fmt.Sprintf("Merge %s: %s", wt.branch, wt.task)
+			}
+			if _, err := git.Run(wt.originalRoot, "merge", "--no-ff", "-m", message, wt.branch); err != nil {
+				// Leave the worktree alone so nothing is lost
+				return "", fmt.Errorf("%v\nThe worktree is still active; resolve the problem or use /worktree keep", err)
+			}
+		}
+
+		a.leaveWorktree(wt)
+		removeErr := removeWorktree(wt, false)
+		result := fmt.Sprintf("Merged %s into %s", wt.branch, wt.originalRoot)
+		if strings.TrimSpace(ahead) == "0" {
+			result = fmt.Sprintf("No changes in %s to merge", wt.branch)
+		}
+		if removeErr != nil {
+			result += fmt.Sprintf("\nWarning: could not clean up worktree: %v", removeErr)
+		}
+		return result, nil
+
+	case "discard":
+		a.leaveWorktree(wt)
+		if err := removeWorktree(wt, true); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Discarded worktree %s and branch %s", wt.path, wt.branch), nil
+
+	case "keep":
+		a.leaveWorktree(wt)
+		return fmt.Sprintf("Left worktree %s on branch %s in place", wt.path, wt.branch), nil
+
+	default:
+		return "", fmt.Errorf("unknown action %q: use merge, discard or keep", action)
+	}
+}
+
+// WorktreeInfo locates a worktree's work
+type WorktreeInfo struct {
+	Path   string
+	Branch string
+}
+
+// SaveWorktree commits anything left uncommitted in the active worktree and
+// leaves it in place, for callers such as --print that exit before it can be
+// merged. The worktree is left even when the commit fails.
+func (a *Agent) SaveWorktree() (WorktreeInfo, error) {
+	wt := a.worktree
+	if wt == nil {
+		return WorktreeInfo{}, fmt.Errorf("not working in a worktree")
+	}
+	err := a.commitWorktreeChanges(wt)
+	a.leaveWorktree(wt)
+	return WorktreeInfo{Path: wt.path, Branch: wt.branch}, err
+}
+
+// commitWorktreeChanges commits anything left uncommitted in the worktree
+func (a *Agent) commitWorktreeChanges(wt *worktreeState) error {
+	out, err := git.Run(wt.path, "status", "--porcelain")
+	if err != nil || strings.TrimSpace(out) == "" {
+		return err
+	}
+
+	if _, err := git.Run(wt.path, "add", "-A"); err != nil {
+		return err
+	}
+
+	files := []string{}
+	names, _ := git.Run(wt.path, "diff", "--cached", "--name-only")
+	for _, name := range strings.Fields(names) {
+		files = append(files, filepath.Join(wt.path, name))
+	}
+
+	fallback := "Agent changes"
+	if wt.task != "" {
+		fallback = wt.task
+	}
+	message := a.generateCommitMessage(wt.path, files, fallback)
+	_, err = git.Run(wt.path, "commit", "-m", message)
+	return err
+}
+
+// leaveWorktree points the session and tools back at the original checkout
+func (a *Agent) leaveWorktree(wt *worktreeState) {
+	os.Chdir(wt.originalDir)
+	a.toolRegistry.SetRoot(wt.toolRoot)
+	a.sessionContext.SetWorkingDir(wt.originalDir)
+	a.sessionContext.RebaseFiles(wt.path, wt.originalRoot)
+	a.indexProject()
+	a.loadInstructions()
+	a.resetCommitState()
+	a.worktree = nil
+}
+
+// worktreeToolRoot is the directory tools confined to toolRoot in the
+// repository at root are confined to in the worktree at path: the same
+// directory inside the worktree, or the whole worktree when toolRoot lies
+// outside the repository. An unconfined registry stays unconfined.
+func worktreeToolRoot(toolRoot, root, path string) string {
+	if toolRoot == "" {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if rel, err := filepath.Rel(root, toolRoot); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Join(path, rel)
+	}
+	return path
+}
+
+func removeWorktree(wt *worktreeState, force bool) error {
+	removeArgs := []string{"worktree", "remove", wt.path}
+	branchFlag := "-d"
+	if force {
+		removeArgs = []string{"worktree", "remove", "--force", wt.path}
+		branchFlag = "-D"
+	}
+
+	if _, err := git.Run(wt.originalRoot, removeArgs...); err != nil {
+		return err
+	}
+	// Drop the <repo>.worktrees directory once the last worktree is gone
+	os.Remove(filepath.Dir(wt.path))
+
+	_, err := git.Run(wt.originalRoot, "branch", branchFlag, wt.branch)
+	return err
+}
+
+func worktreeSlug(task string) string {
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(task), "-"), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimRight(slug[:40], "-")
+	}
+	if slug == "" {
+		slug = fmt.Sprintf("task-%d", time.Now().Unix())
+	}
+	return slug
+}
@@ -0,0 +1,372 @@
+// Package codeindex maintains an on-disk BM25 inverted index over a project's
+// text files, split into line-range chunks and refreshed incrementally.
+package codeindex
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ttli3/go-coding-agent/internal/walker"
+)
+
+const (
+	// chunkLines is how many lines each indexed document spans
+	chunkLines = 30
+	// maxIndexFileSize skips files too large to be hand-written source
+	maxIndexFileSize = 1024 * 1024
+	// maxIndexFiles bounds the index in very large repositories
+	maxIndexFiles = 20000
+	// indexVersion invalidates indexes written by older tokenization logic
+	indexVersion = 1
+
+	// BM25 parameters
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// FileInfo records the state of an indexed file so unchanged files can be skipped
+type FileInfo struct {
+	ModTime int64
+	Size    int64
+}
+
+// Doc is one chunk of a file
+type Doc struct {
+	File      string // slash-separated, relative to the index root
+	StartLine int
+	EndLine   int
+	Length    int // number of terms
+}
+
+// Posting records how often a term occurs in a doc
+type Posting struct {
+	Doc  int32
+	Freq int32
+}
+
+// Index is an inverted index over the chunks of every text file under Root
+type Index struct {
+	Version     int
+	Root        string
+	Files       map[string]FileInfo
+	Docs        []Doc
+	Postings    map[string][]Posting
+	TotalLength int64
+}
+
+// Result is a ranked search hit
+type Result struct {
+	Doc   Doc
+	Score float64
+	// Lines are the lines in the chunk that matched the most query terms
+	Lines []MatchedLine
+}
+
+// MatchedLine is one line of a result snippet
+type MatchedLine struct {
+	Number int
+	Text   string
+}
+
+// Load reads the index stored at path, returning an empty index for root if
+// it is missing, unreadable or was built for a different root or version
+func Load(root, path string) *Index {
+	empty := &Index{
+		Version:  indexVersion,
+		Root:     root,
+		Files:    make(map[string]FileInfo),
+		Postings: make(map[string][]Posting),
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return empty
+	}
+	defer file.Close()
+
+	var idx Index
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(&idx); err != nil {
+		return empty
+	}
+	if idx.Version != indexVersion || idx.Root != root || idx.Files == nil || idx.Postings == nil {
+		return empty
+	}
+	return &idx
+}
+
+// Save writes the index to path
+func (idx *Index) Save(path string) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+
+	w := bufio.NewWriter(file)
+	if err := gob.NewEncoder(w).Encode(idx); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	file.Close()
+
+	return os.Rename(tmp, path)
+}
+
+// Refresh re-indexes files that were added, changed or removed since the last
+// refresh and reports how many files were updated
+func (idx *Index) Refresh() (int, error) {
+	current := make(map[string]FileInfo)
+	paths := make(map[string]string)
+	err := walker.Walk(idx.Root, walker.Options{}, func(path, rel string, d fs.DirEntry) error {
+		if len(current) >= maxIndexFiles {
+			return filepath.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxIndexFileSize {
+			return nil
+		}
+		current[rel] = FileInfo{ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+		paths[rel] = path
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan project: %w", err)
+	}
+
+	stale := make(map[string]bool)
+	var changed []string
+	for rel, info := range idx.Files {
+		if now, ok := current[rel]; !ok || now != info {
+			stale[rel] = true
+		}
+	}
+	for rel, info := range current {
+		if old, ok := idx.Files[rel]; !ok || old != info {
+			changed = append(changed, rel)
+		}
+	}
+	if len(stale) == 0 && len(changed) == 0 {
+		return 0, nil
+	}
+
+	idx.removeFiles(stale)
+
+	sort.Strings(changed)
+	for _, rel := range changed {
+		idx.Files[rel] = current[rel]
+		idx.addFile(paths[rel], rel)
+	}
+
+	updated := len(changed)
+	for rel := range stale {
+		if _, ok := current[rel]; !ok {
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// removeFiles drops every doc belonging to the given files, renumbering the
+// remaining docs and their postings
+func (idx *Index) removeFiles(files map[string]bool) {
+	if len(files) == 0 {
+		return
+	}
+
+	remap := make([]int32, len(idx.Docs))
+	docs := idx.Docs[:0]
+	for i, doc := range idx.Docs {
+		if files[doc.File] {
+			remap[i] = -1
+			idx.TotalLength -= int64(doc.Length)
+			continue
+		}
+		remap[i] = int32(len(docs))
+		docs = append(docs, doc)
+	}
+	idx.Docs = docs
+
+	for term, postings := range idx.Postings {
+		kept := postings[:0]
+		for _, p := range postings {
+			if id := remap[p.Doc]; id >= 0 {
+				kept = append(kept, Posting{Doc: id, Freq: p.Freq})
+			}
+		}
+		if len(kept) == 0 {
+			delete(idx.Postings, term)
+		} else {
+			idx.Postings[term] = kept
+		}
+	}
+
+	for file := range files {
+		delete(idx.Files, file)
+	}
+}
+
+// addFile indexes a text file as consecutive chunks of chunkLines lines
+func (idx *Index) addFile(path, rel string) {
+	content, err := os.ReadFile(path)
+	if err != nil || walker.IsBinary(content) {
+		return
+	}
+
+	lines := strings.Split(string(content), "\n")
+	for start := 0; start < len(lines); start += chunkLines {
+		end := start + chunkLines
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		freqs := make(map[string]int32)
+		length := 0
+		// Include the file path so queries can match on file and directory names
+		if start == 0 {
+			for _, term := range Tokenize(rel) {
+				freqs[term]++
+				length++
+			}
+		}
+		for _, line := range lines[start:end] {
+			for _, term := range Tokenize(line) {
+				freqs[term]++
+				length++
+			}
+		}
+		if length == 0 {
+			continue
+		}
+
+		id := int32(len(idx.Docs))
+		idx.Docs = append(idx.Docs, Doc{File: rel, StartLine: start + 1, EndLine: end, Length: length})
+		idx.TotalLength += int64(length)
+		for term, freq := range freqs {
+			idx.Postings[term] = append(idx.Postings[term], Posting{Doc: id, Freq: freq})
+		}
+	}
+}
+
+// Search ranks chunks against the query with BM25 and returns up to limit
+// results. When pathPrefix is set only files under it are considered.
+func (idx *Index) Search(query string, limit int, pathPrefix string) []Result {
+	terms := queryTerms(query)
+	if len(terms) == 0 || len(idx.Docs) == 0 {
+		return nil
+	}
+
+	n := float64(len(idx.Docs))
+	avgLength := float64(idx.TotalLength) / n
+	pathPrefix = strings.Trim(filepath.ToSlash(pathPrefix), "/")
+	if pathPrefix == "." {
+		pathPrefix = ""
+	}
+
+	scores := make(map[int32]float64)
+	for _, term := range terms {
+		postings := idx.Postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for _, p := range postings {
+			doc := idx.Docs[p.Doc]
+			if pathPrefix != "" && doc.File != pathPrefix && !strings.HasPrefix(doc.File, pathPrefix+"/") {
+				continue
+			}
+			tf := float64(p.Freq)
+			norm := tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(doc.Length)/avgLength))
+			scores[p.Doc] += idf * norm
+		}
+	}
+
+	ids := make([]int32, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	results := make([]Result, 0, len(ids))
+	for _, id := range ids {
+		doc := idx.Docs[id]
+		results = append(results, Result{
+			Doc:   doc,
+			Score: scores[id],
+			Lines: idx.snippet(doc, terms),
+		})
+	}
+	return results
+}
+
+// snippet picks up to three lines of a chunk that match the most query terms
+func (idx *Index) snippet(doc Doc, terms []string) []MatchedLine {
+	content, err := os.ReadFile(filepath.Join(idx.Root, filepath.FromSlash(doc.File)))
+	if err != nil {
+		return nil
+	}
+
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	type scoredLine struct {
+		line  MatchedLine
+		score int
+	}
+	var candidates []scoredLine
+	lines := strings.Split(string(content), "\n")
+	for i := doc.StartLine - 1; i < doc.EndLine && i < len(lines); i++ {
+		matched := make(map[string]bool)
+		for _, term := range Tokenize(lines[i]) {
+			if wanted[term] {
+				matched[term] = true
+			}
+		}
+		if len(matched) > 0 {
+			candidates = append(candidates, scoredLine{
+				line:  MatchedLine{Number: i + 1, Text: strings.TrimSpace(lines[i])},
+				score: len(matched),
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	if len(candidates) > 3 {
+		candidates = candidates[:3]
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].line.Number < candidates[j].line.Number
+	})
+
+	matched := make([]MatchedLine, len(candidates))
+	for i, c := range candidates {
+		matched[i] = c.line
+	}
+	return matched
+}
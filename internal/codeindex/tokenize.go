@@ -0,0 +1,90 @@
+package codeindex
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are dropped from queries so natural-language phrasing doesn't
+// drown out the identifiers that matter
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "can": true, "code": true, "do": true, "does": true,
+	"find": true, "for": true, "from": true, "how": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "the": true, "that": true,
+	"this": true, "to": true, "what": true, "when": true, "where": true,
+	"which": true, "who": true, "why": true, "with": true,
+}
+
+// Tokenize splits text into lowercase index terms. Each identifier yields
+// itself plus its camelCase and snake_case parts, so "parseHTTPRequest"
+// produces "parsehttprequest", "parse", "http" and "request".
+func Tokenize(text string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+	}) {
+		parts := splitIdentifier(word)
+		whole := strings.ToLower(strings.Trim(word, "_"))
+		if len(parts) != 1 && keepTerm(whole) {
+			terms = append(terms, strings.ReplaceAll(whole, "_", ""))
+		}
+		for _, part := range parts {
+			if keepTerm(part) {
+				terms = append(terms, part)
+			}
+		}
+	}
+	return terms
+}
+
+// queryTerms tokenizes a search query, dropping stop words and duplicates
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range Tokenize(query) {
+		if stopWords[term] || seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// splitIdentifier breaks an identifier on underscores, lower-to-upper case
+// changes and the end of acronyms, returning lowercase parts
+func splitIdentifier(word string) []string {
+	var parts []string
+	for _, segment := range strings.Split(word, "_") {
+		runes := []rune(segment)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			boundary := unicode.IsLower(prev) && unicode.IsUpper(cur) ||
+				unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if boundary {
+				parts = append(parts, strings.ToLower(string(runes[start:i])))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			parts = append(parts, strings.ToLower(string(runes[start:])))
+		}
+	}
+	return parts
+}
+
+// keepTerm filters out single characters and bare numbers, which match too
+// broadly to be useful
+func keepTerm(term string) bool {
+	if len(term) < 2 {
+		return false
+	}
+	for _, r := range term {
+		if !unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -68,8 +68,8 @@ func (c *ContextCommand) Execute(args []string, ctx *CommandContext) (string, er
 			if currentTask == "" {
 				return "No current task to complete", nil
 			}
-			// For now, we're not tracking changed files
-			manager.CompleteCurrentTask([]string{})
+			// The session records the files changed while the task was current
+			manager.CompleteCurrentTask(nil)
 			return fmt.Sprintf("Completed task: %s", currentTask), nil
 		}
 
@@ -210,9 +210,8 @@ func (t *TaskCommand) Execute(args []string, ctx *CommandContext) (string, error
 		if currentTask == "" {
 			return "No current task to complete", nil
 		}
-		// For now, we're not tracking changed files
-		// In the future, we could add a GetRecentFiles method to the interface
-		manager.CompleteCurrentTask([]string{})
+		// The session records the files changed while the task was current
+		manager.CompleteCurrentTask(nil)
 		return fmt.Sprintf("Completed task: %s", currentTask), nil
 	}
 
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CommitCommand squashes the agent's commits into one and manages auto-commit
+type CommitCommand struct{}
+
+func (c *CommitCommand) Name() string {
+	return "commit"
+}
+
+func (c *CommitCommand) Description() string {
+	return "Squash agent changes into a single commit with a proper message"
+}
+
+func (c *CommitCommand) Usage() string {
+	return "/commit [message] or /commit auto [on|off]"
+}
+
+func (c *CommitCommand) Execute(args []string, ctx *CommandContext) (string, error) {
+	type GitCommitter interface {
+		CommitAgentChanges(string) (string, error)
+		IsAutoCommitEnabled() bool
+		SetAutoCommit(bool)
+	}
+
+	committer, ok := ctx.Agent.(GitCommitter)
+	if !ok {
+		return "", fmt.Errorf("agent does not support git commits")
+	}
+
+	// Handle auto-commit toggle
+	if len(args) > 0 && args[0] == "auto" {
+		if len(args) == 1 {
+			if committer.IsAutoCommitEnabled() {
+				return "Auto-commit is on: changed files are committed after each turn", nil
+			}
+			return "Auto-commit is off", nil
+		}
+
+		switch args[1] {
+		case "on":
+			committer.SetAutoCommit(true)
+			return "Auto-commit enabled: files the agent changes will be committed after each turn", nil
+		case "off":
+			committer.SetAutoCommit(false)
+			return "Auto-commit disabled", nil
+		default:
+			return "", fmt.Errorf("usage: /commit auto [on|off]")
+		}
+	}
+
+	return committer.CommitAgentChanges(strings.Join(args, " "))
+}
+
+// RollbackCommand undoes the most recent agent commit
+type RollbackCommand struct{}
+
+func (r *RollbackCommand) Name() string {
+	return "rollback"
+}
+
+func (r *RollbackCommand) Description() string {
+	return "Undo the last commit made by the agent"
+}
+
+func (r *RollbackCommand) Usage() string {
+	return "/rollback"
+}
+
+func (r *RollbackCommand) Execute(args []string, ctx *CommandContext) (string, error) {
+	if len(args) > 0 {
+		return "", fmt.Errorf("rollback command takes no arguments")
+	}
+
+	type GitRollbacker interface {
+		RollbackLastCommit() (string, error)
+	}
+
+	if rollbacker, ok := ctx.Agent.(GitRollbacker); ok {
+		return rollbacker.RollbackLastCommit()
+	}
+
+	return "", fmt.Errorf("agent does not support git rollback")
+}
@@ -58,6 +58,7 @@ func (c *HelpCommand) formatAllCommands(registry *CommandRegistry) string {
 	categories := map[string][]Command{
 		"Chat & Session Management": {},
 		"Context & Focus": {},
+		"Git": {},
 		"Model Control": {},
 		"System Information": {},
 	}
@@ -66,10 +67,12 @@ func (c *HelpCommand) formatAllCommands(registry *CommandRegistry) string {
 	commands := registry.ListCommands()
 	for _, cmd := range commands {
 		switch cmd.Name() {
-		case "clear", "exit":
+		case "clear", "exit", "messages", "pin", "unpin":
 			categories["Chat & Session Management"] = append(categories["Chat & Session Management"], cmd)
-		case "context", "focus", "task", "stats":
+		case "context", "focus", "task", "stats", "init", "memory", "plan", "verify":
 			categories["Context & Focus"] = append(categories["Context & Focus"], cmd)
+		case "commit", "rollback", "worktree":
+			categories["Git"] = append(categories["Git"], cmd)
 		case "model":
 			categories["Model Control"] = append(categories["Model Control"], cmd)
 		case "help", "history":
@@ -83,6 +86,7 @@ func (c *HelpCommand) formatAllCommands(registry *CommandRegistry) string {
 	categoryOrder := []string{
 		"Chat & Session Management",
 		"Context & Focus", 
+		"Git",
 		"Model Control",
 		"System Information",
 	}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ttli3/go-coding-agent/internal/mcp"
+)
+
+// MCPCommand shows the configured MCP servers and their tools
+type MCPCommand struct{}
+
+func (m *MCPCommand) Name() string {
+	return "mcp"
+}
+
+func (m *MCPCommand) Description() string {
+	return "Show the status and tools of the configured MCP servers"
+}
+
+func (m *MCPCommand) Usage() string {
+	return "/mcp [server]"
+}
+
+func (m *MCPCommand) Execute(args []string, ctx *CommandContext) (string, error) {
+	type MCPProvider interface {
+		MCPServers() []mcp.Status
+	}
+
+	provider, ok := ctx.Agent.(MCPProvider)
+	if !ok {
+		return "", fmt.Errorf("agent does not support MCP servers")
+	}
+
+	servers := provider.MCPServers()
+	if len(servers) == 0 {
+		return "No MCP servers are configured. Add them under mcp.servers in ~/.agent_go.yaml", nil
+	}
+
+	if len(args) > 0 {
+		for _, server := range servers {
+			if server.Name == args[0] {
+				return formatMCPServer(server, true), nil
+			}
+		}
+		return "", fmt.Errorf("no MCP server named %s", args[0])
+	}
+
+	var out strings.Builder
+	out.WriteString("MCP servers:\n")
+	for _, server := range servers {
+		out.WriteString(formatMCPServer(server, false))
+	}
+	out.WriteString("Use /mcp <server> to list its tools")
+	return out.String(), nil
+}
+
+// formatMCPServer describes a server, listing its tools when detailed
+func formatMCPServer(server mcp.Status, detailed bool) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("  %s (%s: %s)\n", server.Name, server.Transport, server.Target))
+	if server.Error != "" {
+		out.WriteString(fmt.Sprintf("    failed: %s\n", server.Error))
+		return out.String()
+	}
+
+	status := fmt.Sprintf("connected, %d tools", len(server.Tools))
+	if server.Server != "" {
+		status += ", server " + server.Server
+	}
+	out.WriteString("    " + status + "\n")
+	if !detailed {
+		return out.String()
+	}
+
+	for _, tool := range server.Tools {
+		line := "    - " + tool.Name
+		if tool.ReadOnly {
+			line += " (read-only)"
+		}
+		if description := strings.SplitN(strings.TrimSpace(tool.Description), "\n", 2)[0]; description != "" {
+			line += ": " + description
+		}
+		out.WriteString(line + "\n")
+	}
+	return strings.TrimRight(out.String(), "\n")
+}
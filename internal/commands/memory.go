@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// InitCommand has the agent analyze the repository and draft AGENTS.md
+type InitCommand struct{}
+
+func (i *InitCommand) Name() string {
+	return "init"
+}
+
+func (i *InitCommand) Description() string {
+	return "Analyze the repository and write an AGENTS.md instruction file"
+}
+
+func (i *InitCommand) Usage() string {
+	return "/init"
+}
+
+func (i *InitCommand) Execute(args []string, ctx *CommandContext) (string, error) {
+	if len(args) > 0 {
+		return "", fmt.Errorf("init command takes no arguments")
+	}
+
+	type InstructionInitializer interface {
+		InitInstructions() (string, error)
+	}
+
+	initializer, ok := ctx.Agent.(InstructionInitializer)
+	if !ok {
+		return "", fmt.Errorf("agent does not support instruction files")
+	}
+
+	return initializer.InitInstructions()
+}
+
+// MemoryCommand shows and edits the instruction files loaded into the prompt
+type MemoryCommand struct{}
+
+func (m *MemoryCommand) Name() string {
+	return "memory"
+}
+
+func (m *MemoryCommand) Description() string {
+	return "View and edit the AGENTS.md instruction files loaded into the prompt"
+}
+
+func (m *MemoryCommand) Usage() string {
+	return "/memory [show|edit [global|project|<dir>]|add <text>|reload]"
+}
+
+func (m *MemoryCommand) Execute(args []string, ctx *CommandContext) (string, error) {
+	type InstructionManager interface {
+		InstructionSummary() string
+		InstructionContents() string
+		GlobalInstructionPath() string
+		ProjectInstructionPath() string
+		AddInstruction(string) (string, error)
+		ReloadInstructions()
+	}
+
+	manager, ok := ctx.Agent.(InstructionManager)
+	if !ok {
+		return "", fmt.Errorf("agent does not support instruction files")
+	}
+
+	if len(args) == 0 {
+		return manager.InstructionSummary(), nil
+	}
+
+	switch args[0] {
+	case "show":
+		return manager.InstructionContents(), nil
+
+	case "reload":
+		manager.ReloadInstructions()
+		return manager.InstructionSummary(), nil
+
+	case "add":
+		if len(args) < 2 {
+			return "", fmt.Errorf("usage: /memory add <text>")
+		}
+		return manager.AddInstruction(strings.Join(args[1:], " "))
+
+	case "edit":
+		path := manager.ProjectInstructionPath()
+		if len(args) > 1 {
+			switch args[1] {
+			case "project":
+			case "global":
+				path = manager.GlobalInstructionPath()
+			default:
+				path = filepath.Join(args[1], filepath.Base(path))
+			}
+		}
+		if path == "" {
+			return "", fmt.Errorf("could not determine instruction file location")
+		}
+
+		if err := openInEditor(path); err != nil {
+			return "", err
+		}
+		manager.ReloadInstructions()
+		return fmt.Sprintf("Saved %s and reloaded instructions", path), nil
+
+	default:
+		return "", fmt.Errorf("unknown memory action: %s\nUsage: %s", args[0], m.Usage())
+	}
+}
+
+// openInEditor opens path in $VISUAL or $EDITOR (vi by default), creating it first
+func openInEditor(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.WriteFile(path, []byte("# Agent Instructions\n\n"), 0644); err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The editor variable may carry flags, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", parts[0], err)
+	}
+	return nil
+}
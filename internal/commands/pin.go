@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultMessageListLimit is how many recent messages /messages shows
+const defaultMessageListLimit = 20
+
+// PinCommand pins messages so they survive trimming and /clear
+type PinCommand struct{}
+
+func (p *PinCommand) Name() string {
+	return "pin"
+}
+
+func (p *PinCommand) Description() string {
+	return "Pin messages so they are kept through trimming and /clear"
+}
+
+func (p *PinCommand) Usage() string {
+	return "/pin <message-id...>"
+}
+
+func (p *PinCommand) Execute(args []string, ctx *CommandContext) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: %s (see /messages for IDs)", p.Usage())
+	}
+
+	type MessagePinner interface {
+		PinMessage(string) error
+	}
+
+	pinner, ok := ctx.Agent.(MessagePinner)
+	if !ok {
+		return "", fmt.Errorf("agent does not support pinning messages")
+	}
+
+	var pinned []string
+	for _, id := range args {
+		if err := pinner.PinMessage(id); err != nil {
+			if len(pinned) > 0 {
+				return "", fmt.Errorf("pinned %s, then: %w", strings.Join(pinned, ", "), err)
+			}
+			return "", err
+		}
+		pinned = append(pinned, id)
+	}
+
+	return fmt.Sprintf("Pinned %s", strings.Join(pinned, ", ")), nil
+}
+
+// UnpinCommand removes pins from messages
+type UnpinCommand struct{}
+
+func (u *UnpinCommand) Name() string {
+	return "unpin"
+}
+
+func (u *UnpinCommand) Description() string {
+	return "Unpin messages"
+}
+
+func (u *UnpinCommand) Usage() string {
+	return "/unpin <message-id...|all>"
+}
+
+func (u *UnpinCommand) Execute(args []string, ctx *CommandContext) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: %s", u.Usage())
+	}
+
+	type MessageUnpinner interface {
+		UnpinMessage(string) (int, error)
+	}
+
+	unpinner, ok := ctx.Agent.(MessageUnpinner)
+	if !ok {
+		return "", fmt.Errorf("agent does not support pinning messages")
+	}
+
+	total := 0
+	for _, id := range args {
+		count, err := unpinner.UnpinMessage(id)
+		if err != nil {
+			return "", err
+		}
+		total += count
+	}
+
+	return fmt.Sprintf("Unpinned %d messages", total), nil
+}
+
+// MessagesCommand lists conversation messages with their IDs
+type MessagesCommand struct{}
+
+func (m *MessagesCommand) Name() string {
+	return "messages"
+}
+
+func (m *MessagesCommand) Description() string {
+	return "List conversation messages with their IDs and pin state"
+}
+
+func (m *MessagesCommand) Usage() string {
+	return "/messages [count|all]"
+}
+
+func (m *MessagesCommand) Execute(args []string, ctx *CommandContext) (string, error) {
+	type MessageLister interface {
+		ListMessages(int) string
+	}
+
+	lister, ok := ctx.Agent.(MessageLister)
+	if !ok {
+		return "", fmt.Errorf("agent does not support listing messages")
+	}
+
+	limit := defaultMessageListLimit
+	if len(args) > 0 {
+		if args[0] == "all" {
+			limit = 0
+		} else {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				return "", fmt.Errorf("usage: %s", m.Usage())
+			}
+			limit = n
+		}
+	}
+
+	return lister.ListMessages(limit), nil
+}
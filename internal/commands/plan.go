@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+)
+
+// PlanCommand toggles plan mode and reviews the proposed plan
+type PlanCommand struct{}
+
+func (p *PlanCommand) Name() string {
+	return "plan"
+}
+
+func (p *PlanCommand) Description() string {
+	return "Investigate read-only and review a plan before any changes are made"
+}
+
+func (p *PlanCommand) Usage() string {
+	return "/plan [on|off|status|approve|edit|reject]"
+}
+
+func (p *PlanCommand) Execute(args []string, ctx *CommandContext) (string, error) {
+	type PlanManager interface {
+		InPlanMode() bool
+		SetPlanMode(bool) string
+		PlanStatus() string
+		ProposedPlan() string
+		ApprovePlan(string) (string, error)
+		RejectPlan() string
+	}
+
+	manager, ok := ctx.Agent.(PlanManager)
+	if !ok {
+		return "", fmt.Errorf("agent does not support plan mode")
+	}
+
+	if len(args) == 0 {
+		return manager.SetPlanMode(!manager.InPlanMode()), nil
+	}
+
+	switch args[0] {
+	case "on":
+		return manager.SetPlanMode(true), nil
+	case "off":
+		return manager.SetPlanMode(false), nil
+	case "status", "show":
+		return manager.PlanStatus(), nil
+	case "approve":
+		result, err := manager.ApprovePlan("")
+		if err != nil {
+			return "", err
+		}
+		return result + ". Send a message (e.g. \"go\") to start executing it", nil
+	case "edit":
+		if manager.ProposedPlan() == "" {
+			return "", fmt.Errorf("no plan to edit; ask the agent for a numbered plan first")
+		}
+		plan, err := EditPlan(manager.ProposedPlan())
+		if err != nil {
+			return "", err
+		}
+		result, err := manager.ApprovePlan(plan)
+		if err != nil {
+			return "", err
+		}
+		return result + ". Send a message (e.g. \"go\") to start executing it", nil
+	case "reject":
+		return manager.RejectPlan(), nil
+	default:
+		return "", fmt.Errorf("unknown plan action: %s\nUsage: %s", args[0], p.Usage())
+	}
+}
+
+// EditPlan opens plan in the user's editor and returns the edited text
+func EditPlan(plan string) (string, error) {
+	file, err := os.CreateTemp("", "agent_go_plan_*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create plan file: %w", err)
+	}
+	path := file.Name()
+	defer os.Remove(path)
+
+	_, err = file.WriteString(plan + "\n")
+	file.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to write plan file: %w", err)
+	}
+
+	if err := openInEditor(path); err != nil {
+		return "", err
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited plan: %w", err)
+	}
+	return string(edited), nil
+}
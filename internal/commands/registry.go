@@ -6,10 +6,22 @@ func NewDefaultRegistry() *CommandRegistry {
 	
 	// register all default commands
 	registry.Register(&ClearCommand{})
+	registry.Register(&MessagesCommand{})
+	registry.Register(&PinCommand{})
+	registry.Register(&UnpinCommand{})
 	registry.Register(&ContextCommand{})
 	registry.Register(&FocusCommand{})
+	registry.Register(&InitCommand{})
+	registry.Register(&MemoryCommand{})
+	registry.Register(&PlanCommand{})
+	registry.Register(&VerifyCommand{})
+
+	registry.Register(&CommitCommand{})
+	registry.Register(&RollbackCommand{})
+	registry.Register(&WorktreeCommand{})
 
 	registry.Register(&ModelCommand{})
+	registry.Register(&MCPCommand{})
 	registry.Register(&HelpCommand{})
 	registry.Register(&ExitCommand{})
 	
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VerifyCommand toggles the checks run before the agent finishes a turn
+type VerifyCommand struct{}
+
+func (v *VerifyCommand) Name() string {
+	return "verify"
+}
+
+func (v *VerifyCommand) Description() string {
+	return "Run build and test checks before the agent finishes a turn that changed files"
+}
+
+func (v *VerifyCommand) Usage() string {
+	return "/verify [on|off|status]"
+}
+
+func (v *VerifyCommand) Execute(args []string, ctx *CommandContext) (string, error) {
+	type Verifier interface {
+		IsVerifyEnabled() bool
+		SetVerify(bool)
+		VerifyChecks() []string
+	}
+
+	verifier, ok := ctx.Agent.(Verifier)
+	if !ok {
+		return "", fmt.Errorf("agent does not support verification")
+	}
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "on":
+			verifier.SetVerify(true)
+		case "off":
+			verifier.SetVerify(false)
+			return "Verification disabled", nil
+		case "status":
+		default:
+			return "", fmt.Errorf("usage: %s", v.Usage())
+		}
+	}
+
+	if !verifier.IsVerifyEnabled() {
+		return "Verification is off. Enable it with /verify on", nil
+	}
+	checks := verifier.VerifyChecks()
+	if len(checks) == 0 {
+		return "Verification is on, but no build or test commands were detected; set verify.commands in the config", nil
+	}
+	return "Verification is on. Before finishing a turn that changed files, the agent runs the checks for the changed sub-projects, e.g.:\n  " + strings.Join(checks, "\n  "), nil
+}
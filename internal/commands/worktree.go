@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WorktreeCommand runs a task in an isolated git worktree
+type WorktreeCommand struct{}
+
+func (w *WorktreeCommand) Name() string {
+	return "worktree"
+}
+
+func (w *WorktreeCommand) Description() string {
+	return "Work on a task in an isolated git worktree, then merge or discard it"
+}
+
+func (w *WorktreeCommand) Usage() string {
+	return "/worktree <task> or /worktree [status|merge|discard|keep]"
+}
+
+func (w *WorktreeCommand) Execute(args []string, ctx *CommandContext) (string, error) {
+	type WorktreeManager interface {
+		StartWorktree(string) (string, error)
+		FinishWorktree(string) (string, error)
+		WorktreeStatus() string
+	}
+
+	manager, ok := ctx.Agent.(WorktreeManager)
+	if !ok {
+		return "", fmt.Errorf("agent does not support worktrees")
+	}
+
+	if len(args) == 0 || (len(args) == 1 && args[0] == "status") {
+		return manager.WorktreeStatus(), nil
+	}
+
+	if len(args) == 1 {
+		switch args[0] {
+		case "merge", "discard", "keep":
+			return manager.FinishWorktree(args[0])
+		}
+	}
+
+	return manager.StartWorktree(strings.Join(args, " "))
+}
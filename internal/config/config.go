@@ -11,6 +11,11 @@ import (
 type Config struct {
 	OpenRouter OpenRouterConfig `mapstructure:"openrouter"`
 	Agent      AgentConfig      `mapstructure:"agent"`
+	Git        GitConfig        `mapstructure:"git"`
+	Hooks      HooksConfig      `mapstructure:"hooks"`
+	Verify     VerifyConfig     `mapstructure:"verify"`
+	MCP        MCPConfig        `mapstructure:"mcp"`
+	Plugins    PluginsConfig    `mapstructure:"plugins"`
 }
 
 type OpenRouterConfig struct {
@@ -20,12 +25,98 @@ type OpenRouterConfig struct {
 }
 
 type AgentConfig struct {
-	ConfirmDestructive bool    `mapstructure:"confirm_destructive"`
-	MaxTokens          int     `mapstructure:"max_tokens"`
-	Temperature        float64 `mapstructure:"temperature"`
+	ConfirmDestructive bool     `mapstructure:"confirm_destructive"`
+	MaxTokens          int      `mapstructure:"max_tokens"`
+	Temperature        float64  `mapstructure:"temperature"`
+	RepoMapTokens      int      `mapstructure:"repo_map_tokens"`
+	FocusTokens        int      `mapstructure:"focus_tokens"`
+	CompactAfterTurns  int      `mapstructure:"compact_after_turns"`
+	PinnedTokens       int      `mapstructure:"pinned_tokens"`
+	AutoPinKeywords    []string `mapstructure:"auto_pin_keywords"`
+	DelegateMaxSteps   int      `mapstructure:"delegate_max_steps"`
+	ParallelTools      int      `mapstructure:"parallel_tools"`
+	GoFormat           bool     `mapstructure:"go_format"`
+	GoVet              bool     `mapstructure:"go_vet"`
+}
+
+type GitConfig struct {
+	AutoCommit   bool   `mapstructure:"auto_commit"`
+	CommitBranch string `mapstructure:"commit_branch"`
+}
+
+// HooksConfig lists the shell commands run at each lifecycle event
+type HooksConfig struct {
+	SessionStart     []HookConfig `mapstructure:"session_start"`
+	UserPromptSubmit []HookConfig `mapstructure:"user_prompt_submit"`
+	PreToolUse       []HookConfig `mapstructure:"pre_tool_use"`
+	PostToolUse      []HookConfig `mapstructure:"post_tool_use"`
+	TurnEnd          []HookConfig `mapstructure:"turn_end"`
+}
+
+type HookConfig struct {
+	Matcher string `mapstructure:"matcher"` // regular expression on the tool name, for tool events
+	Command string `mapstructure:"command"`
+	Timeout int    `mapstructure:"timeout"` // seconds
+}
+
+// VerifyConfig controls the checks run before the agent finishes a turn that
+// changed files
+type VerifyConfig struct {
+	Enabled   bool     `mapstructure:"enabled"`
+	Steps     []string `mapstructure:"steps"`    // detected commands to run: build, test, lint
+	Commands  []string `mapstructure:"commands"` // explicit commands, run from the project root instead of the steps
+	MaxRounds int      `mapstructure:"max_rounds"`
+	Timeout   int      `mapstructure:"timeout"` // seconds, per command
+}
+
+// MCPConfig lists the Model Context Protocol servers whose tools are offered
+// to the AI
+type MCPConfig struct {
+	Servers []MCPServerConfig `mapstructure:"servers"`
+	Timeout int               `mapstructure:"timeout"` // seconds, per request
+}
+
+// MCPServerConfig describes one server: a command for the stdio transport,
+// or a url for the streamable HTTP transport
+type MCPServerConfig struct {
+	Name     string            `mapstructure:"name"`
+	Command  string            `mapstructure:"command"`
+	Args     []string          `mapstructure:"args"`
+	Env      []string          `mapstructure:"env"` // KEY=VALUE
+	URL      string            `mapstructure:"url"`
+	Headers  map[string]string `mapstructure:"headers"`
+	Disabled bool              `mapstructure:"disabled"`
+
+	// Tools run without approval only when read-only: those named in
+	// ReadOnlyTools, plus those annotated read-only if the server is Trusted
+	Trusted       bool     `mapstructure:"trusted"`
+	ReadOnlyTools []string `mapstructure:"read_only_tools"`
+}
+
+// PluginsConfig decides which tool plugins to trust. It is only read from
+// ~/.agent_go.yaml, so a project's own config can't trust its plugins.
+type PluginsConfig struct {
+	TrustedDirs []string `mapstructure:"trusted_dirs"` // projects whose .agent_go/tools plugins are loaded
+	ReadOnly    []string `mapstructure:"read_only"`    // plugins that run without approval
 }
 
 func Load() (*Config, error) {
+	config, err := LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	// validate required fields
+	if config.OpenRouter.APIKey == "" {
+		return nil, fmt.Errorf("OpenRouter API key is required. Set OPENROUTER_API_KEY environment variable or add to config file")
+	}
+
+	return config, nil
+}
+
+// LoadSettings reads the configuration without requiring an API key, for
+// commands that don't talk to the model
+func LoadSettings() (*Config, error) {
 	viper.SetConfigName(".agent_go")
 	viper.SetConfigType("yaml")
 
@@ -40,6 +131,26 @@ func Load() (*Config, error) {
 	viper.SetDefault("agent.confirm_destructive", true)
 	viper.SetDefault("agent.max_tokens", 4000)
 	viper.SetDefault("agent.temperature", 0.7)
+	viper.SetDefault("agent.repo_map_tokens", 1024)
+	viper.SetDefault("agent.focus_tokens", 8000)
+	viper.SetDefault("agent.compact_after_turns", 3)
+	viper.SetDefault("agent.pinned_tokens", 4000)
+	viper.SetDefault("agent.auto_pin_keywords", []string{})
+	viper.SetDefault("agent.delegate_max_steps", 15)
+	viper.SetDefault("agent.parallel_tools", 4)
+	viper.SetDefault("agent.go_format", true)
+	viper.SetDefault("agent.go_vet", false)
+	viper.SetDefault("git.auto_commit", false)
+	viper.SetDefault("git.commit_branch", "")
+	viper.SetDefault("verify.enabled", false)
+	viper.SetDefault("verify.steps", []string{"build", "test"})
+	viper.SetDefault("verify.commands", []string{})
+	viper.SetDefault("verify.max_rounds", 2)
+	viper.SetDefault("verify.timeout", 300)
+	viper.SetDefault("mcp.servers", []MCPServerConfig{})
+	viper.SetDefault("mcp.timeout", 60)
+	viper.SetDefault("plugins.trusted_dirs", []string{})
+	viper.SetDefault("plugins.read_only", []string{})
 
 	// env variables
 	viper.SetEnvPrefix("GOAGENT")
@@ -59,10 +170,14 @@ func Load() (*Config, error) {
 	if err := viper.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
-
-	// validate required fields
-	if config.OpenRouter.APIKey == "" {
-		return nil, fmt.Errorf("OpenRouter API key is required. Set OPENROUTER_API_KEY environment variable or add to config file")
+	// A config file found in the working directory comes with the project,
+	// so it can't decide which plugins to trust or run commands of its own
+	if used := viper.ConfigFileUsed(); used != "" && (home == "" || filepath.Dir(used) != home) {
+		config.Plugins = PluginsConfig{}
+		config.Hooks = HooksConfig{}
+		config.MCP.Servers = nil
+		config.Verify.Enabled = false
+		config.Verify.Commands = nil
 	}
 
 	return &config, nil
@@ -89,6 +204,41 @@ agent:
   confirm_destructive: true
   max_tokens: 4000
   temperature: 0.7
+  repo_map_tokens: 1024
+  focus_tokens: 8000
+  compact_after_turns: 3
+  pinned_tokens: 4000
+  auto_pin_keywords: []
+  delegate_max_steps: 15
+  parallel_tools: 4
+  go_format: true
+  go_vet: false
+
+git:
+  auto_commit: false
+  commit_branch: ""
+
+hooks:
+  session_start: []
+  user_prompt_submit: []
+  pre_tool_use: []
+  post_tool_use: []
+  turn_end: []
+
+verify:
+  enabled: false
+  steps: ["build", "test"]
+  commands: []
+  max_rounds: 2
+  timeout: 300
+
+mcp:
+  servers: []
+  timeout: 60
+
+plugins:
+  trusted_dirs: []
+  read_only: []
 `
 
 	return os.WriteFile(configPath, []byte(defaultConfig), 0644)
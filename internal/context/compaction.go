@@ -0,0 +1,182 @@
+package context
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ToolOutput is one tool result inside a tool results message. Once it is old
+// enough, Content is replaced by Placeholder in the message.
+type ToolOutput struct {
+	Tool        string `json:"tool"`
+	Content     string `json:"content"`
+	Placeholder string `json:"placeholder"`
+	Compacted   bool   `json:"compacted"`
+}
+
+// CompactionPolicy decides when old tool results are replaced by placeholders
+type CompactionPolicy struct {
+	// AfterTurns is how many user turns a tool result survives verbatim; 0 disables compaction
+	AfterTurns int
+	// ToolTurns overrides AfterTurns for specific tools; a negative value never compacts
+	ToolTurns map[string]int
+	// MinTokens leaves results smaller than this alone, since placeholders wouldn't save much
+	MinTokens int
+}
+
+// DefaultToolTurns holds the per-tool rules: bulky, easily repeated reads
+// are compacted sooner, and results of changes are kept verbatim
+var DefaultToolTurns = map[string]int{
+	"read_file":       1,
+	"grep_search":     1,
+	"search_code":     1,
+	"search_codebase": 1,
+	"list_directory":  1,
+	"find_files":      1,
+	"todo_write":      1,
+	"todo_read":       1,
+	"run_tests":       1,
+	"write_file":      -1,
+	"edit_file":       -1,
+	"replace_content": -1,
+}
+
+// NewCompactionPolicy creates a policy with the default per-tool rules
+func NewCompactionPolicy(afterTurns int) CompactionPolicy {
+	toolTurns := make(map[string]int, len(DefaultToolTurns))
+	for tool, turns := range DefaultToolTurns {
+		toolTurns[tool] = turns
+	}
+	return CompactionPolicy{AfterTurns: afterTurns, ToolTurns: toolTurns, MinTokens: 200}
+}
+
+// turnsFor returns how many turns a result of tool is kept verbatim, or -1 for never compacting
+func (p CompactionPolicy) turnsFor(tool string) int {
+	if p.AfterTurns <= 0 {
+		return -1
+	}
+	if turns, ok := p.ToolTurns[tool]; ok {
+		if turns < 0 {
+			return -1
+		}
+		// Tool rules can only compact sooner than the overall policy
+		if turns < p.AfterTurns {
+			return turns
+		}
+	}
+	return p.AfterTurns
+}
+
+// CompactionStats accounts for the tool results replaced by placeholders
+type CompactionStats struct {
+	Results     int
+	TokensSaved int
+	ByTool      map[string]*ToolCompaction
+}
+
+// ToolCompaction is the per-tool share of CompactionStats
+type ToolCompaction struct {
+	Results     int
+	TokensSaved int
+}
+
+// SetCompactionPolicy replaces the policy used for tool result compaction
+func (cw *ContextWindow) SetCompactionPolicy(policy CompactionPolicy) {
+	cw.Compaction = policy
+}
+
+// AddToolResults adds a message holding tool results, remembering each output
+// so it can be compacted later
+func (cw *ContextWindow) AddToolResults(role, content string, pin bool, outputs []ToolOutput) {
+	cw.AddMessage(role, content, pin)
+
+	// AddMessage may have trimmed, but the newest message is always kept
+	last := len(cw.Messages) - 1
+	cw.Messages[last].Turn = cw.Turn
+	cw.Messages[last].ToolOutputs = outputs
+}
+
+// StartTurn marks the beginning of a new user turn and compacts tool results
+// that have aged past the policy
+func (cw *ContextWindow) StartTurn() {
+	cw.Turn++
+	cw.compactToolResults()
+}
+
+// compactToolResults replaces old tool outputs with their placeholders.
+// Pinned messages are left intact.
+func (cw *ContextWindow) compactToolResults() {
+	for i := range cw.Messages {
+		msg := &cw.Messages[i]
+		if len(msg.ToolOutputs) == 0 || msg.Pinned {
+			continue
+		}
+
+		age := cw.Turn - msg.Turn
+		changed := false
+		for j := range msg.ToolOutputs {
+			out := &msg.ToolOutputs[j]
+			turns := cw.Compaction.turnsFor(out.Tool)
+			if out.Compacted || turns < 0 || age <= turns || out.Placeholder == "" {
+				continue
+			}
+
+			saved := EstimateTokens(out.Content) - EstimateTokens(out.Placeholder)
+			if EstimateTokens(out.Content) < cw.Compaction.MinTokens || saved <= 0 {
+				continue
+			}
+			if !strings.Contains(msg.Content, out.Content) {
+				continue
+			}
+
+			msg.Content = strings.Replace(msg.Content, out.Content, out.Placeholder, 1)
+			out.Compacted = true
+			out.Content = ""
+			changed = true
+			cw.recordCompaction(out.Tool, saved)
+		}
+
+		if changed {
+			msg.Tokens = EstimateTokens(msg.Content)
+		}
+	}
+}
+
+func (cw *ContextWindow) recordCompaction(tool string, saved int) {
+	if cw.CompactionStats.ByTool == nil {
+		cw.CompactionStats.ByTool = make(map[string]*ToolCompaction)
+	}
+	stats, ok := cw.CompactionStats.ByTool[tool]
+	if !ok {
+		stats = &ToolCompaction{}
+		cw.CompactionStats.ByTool[tool] = stats
+	}
+
+	stats.Results++
+	stats.TokensSaved += saved
+	cw.CompactionStats.Results++
+	cw.CompactionStats.TokensSaved += saved
+}
+
+// compactionSummary formats the compaction accounting for GetContextStats
+func (cw *ContextWindow) compactionSummary() string {
+	if cw.Compaction.AfterTurns <= 0 {
+		return "- Tool result compaction: off"
+	}
+
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("- Tool result compaction: after %d turns (turn %d)\n", cw.Compaction.AfterTurns, cw.Turn))
+	summary.WriteString(fmt.Sprintf("- Compacted tool results: %d (~%d tokens saved)", cw.CompactionStats.Results, cw.CompactionStats.TokensSaved))
+
+	tools := make([]string, 0, len(cw.CompactionStats.ByTool))
+	for tool := range cw.CompactionStats.ByTool {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	for _, tool := range tools {
+		stats := cw.CompactionStats.ByTool[tool]
+		summary.WriteString(fmt.Sprintf("\n    %s: %d results, ~%d tokens", tool, stats.Results, stats.TokensSaved))
+	}
+	return summary.String()
+}
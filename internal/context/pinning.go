@@ -0,0 +1,108 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pin marks a message so it survives trimming, compaction and /clear, as long
+// as it fits in the pinned token budget
+func (cw *ContextWindow) Pin(messageID string) error {
+	msg := cw.findMessage(messageID)
+	if msg == nil {
+		return fmt.Errorf("no message with ID %s (see /messages)", messageID)
+	}
+	if msg.Pinned {
+		return nil
+	}
+	if index := cw.systemPromptIndex(); index >= 0 && &cw.Messages[index] == msg {
+		return fmt.Errorf("%s is the system prompt, which is always kept", messageID)
+	}
+
+	if used := cw.pinnedTokens(); used+msg.Tokens > cw.PinnedTokenBudget {
+		return fmt.Errorf("pinning %s (~%d tokens) would exceed the pinned budget (~%d of %d tokens used); unpin something first",
+			messageID, msg.Tokens, used, cw.PinnedTokenBudget)
+	}
+
+	msg.Pinned = true
+	return nil
+}
+
+// Unpin removes the pin from a message
+func (cw *ContextWindow) Unpin(messageID string) error {
+	msg := cw.findMessage(messageID)
+	if msg == nil {
+		return fmt.Errorf("no message with ID %s (see /messages)", messageID)
+	}
+	msg.Pinned = false
+	return nil
+}
+
+// UnpinAll removes every pin, returning how many messages were unpinned
+func (cw *ContextWindow) UnpinAll() int {
+	count := 0
+	for i := range cw.Messages {
+		if cw.Messages[i].Pinned {
+			cw.Messages[i].Pinned = false
+			count++
+		}
+	}
+	return count
+}
+
+// ListMessages formats the most recent limit messages (all when limit <= 0)
+// with their IDs, pin state and a preview
+func (cw *ContextWindow) ListMessages(limit int) string {
+	if len(cw.Messages) == 0 {
+		return "No messages in the conversation"
+	}
+
+	start := 0
+	if limit > 0 && len(cw.Messages) > limit {
+		start = len(cw.Messages) - limit
+	}
+
+	var list strings.Builder
+	list.WriteString(fmt.Sprintf("Messages (%d of %d, pinned ~%d of %d tokens):\n",
+		len(cw.Messages)-start, len(cw.Messages), cw.pinnedTokens(), cw.PinnedTokenBudget))
+	for _, msg := range cw.Messages[start:] {
+		marker := " "
+		if msg.Pinned {
+			marker = "*"
+		}
+		preview := strings.Join(strings.Fields(msg.Content), " ")
+		list.WriteString(fmt.Sprintf("%s %-5s %-9s %6d tok  %s\n", marker, msg.MessageID, msg.Role, msg.Tokens, truncateContent(preview, 70)))
+	}
+	list.WriteString("(* = pinned)")
+	return list.String()
+}
+
+func (cw *ContextWindow) findMessage(messageID string) *ConversationMessage {
+	for i := range cw.Messages {
+		if cw.Messages[i].MessageID == messageID {
+			return &cw.Messages[i]
+		}
+	}
+	return nil
+}
+
+// pinnedTokens is how much of the pinned budget is in use
+func (cw *ContextWindow) pinnedTokens() int {
+	total := 0
+	for _, msg := range cw.Messages {
+		if msg.Pinned {
+			total += msg.Tokens
+		}
+	}
+	return total
+}
+
+func (cw *ContextWindow) pinnedCount() int {
+	count := 0
+	for _, msg := range cw.Messages {
+		if msg.Pinned {
+			count++
+		}
+	}
+	return count
+}
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/ttli3/go-coding-agent/internal/project"
 )
 
 // SessionContext holds the current session state
@@ -18,8 +20,11 @@ type SessionContext struct {
 	RecentFiles     []string          `json:"recent_files"`
 	CurrentTask     string            `json:"current_task"`
 	TaskHistory     []CompletedTask   `json:"task_history"`
+	TaskFiles       []string          `json:"task_files"` // Files changed since the current task was set
+	Todos           []TodoItem        `json:"todos"`
 	UserPreferences map[string]string `json:"user_preferences"`
 	ProjectType     string            `json:"project_type"`
+	Layout          *project.Layout   `json:"-"` // Detected languages and sub-projects
 
 	SessionID       string            `json:"session_id"`
 	CreatedAt       time.Time         `json:"created_at"`
@@ -51,6 +56,82 @@ func NewSessionContext() *SessionContext {
 	}
 }
 
+// Fork returns a fresh session for the same project, so a sub-agent can
+// track files without touching this one
+func (sc *SessionContext) Fork() *SessionContext {
+	return &SessionContext{
+		FocusedFiles:    []string{},
+		OpenFiles:       make(map[string]string),
+		WorkingDir:      sc.WorkingDir,
+		ProjectRoot:     sc.ProjectRoot,
+		RecentFiles:     []string{},
+		TaskHistory:     []CompletedTask{},
+		UserPreferences: make(map[string]string),
+		ProjectType:     sc.ProjectType,
+		Layout:          sc.Layout,
+
+		SessionID:       generateSessionID(),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+}
+
+// SetWorkingDir points the session at dir and re-detects the project root and type
+func (sc *SessionContext) SetWorkingDir(dir string) {
+	sc.WorkingDir = dir
+	sc.ProjectRoot = findProjectRoot(dir)
+	sc.DetectProjectType()
+	sc.UpdatedAt = time.Now()
+}
+
+// RebaseFiles rewrites focused and recent file paths under oldRoot to live under newRoot
+func (sc *SessionContext) RebaseFiles(oldRoot, newRoot string) {
+	rebase := func(files []string) {
+		for i, file := range files {
+			if rel, err := filepath.Rel(oldRoot, file); err == nil && !strings.HasPrefix(rel, "..") {
+				files[i] = filepath.Join(newRoot, rel)
+			}
+		}
+	}
+	rebase(sc.FocusedFiles)
+	rebase(sc.RecentFiles)
+	sc.UpdatedAt = time.Now()
+}
+
+// AgentDir returns the per-project directory used for caches and indexes,
+// creating it (with a .gitignore that ignores its contents) if needed
+func (sc *SessionContext) AgentDir() (string, error) {
+	root := sc.ProjectRoot
+	if root == "" {
+		root = sc.WorkingDir
+	}
+
+	dir := filepath.Join(root, ".agent_go")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create agent directory: %w", err)
+	}
+
+	ignorePath := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignorePath); os.IsNotExist(err) {
+		os.WriteFile(ignorePath, []byte("*\n"), 0644)
+	}
+	return dir, nil
+}
+
+// MarkFileSeen records the content hash of a file as last shown to the model
+func (sc *SessionContext) MarkFileSeen(path, hash string) {
+	if sc.OpenFiles == nil {
+		sc.OpenFiles = make(map[string]string)
+	}
+	sc.OpenFiles[path] = hash
+}
+
+// LastSeenHash returns the content hash of a file as last shown to the model
+func (sc *SessionContext) LastSeenHash(path string) (string, bool) {
+	hash, ok := sc.OpenFiles[path]
+	return hash, ok
+}
+
 // AddFocusedFile adds a file to the focused files list
 func (sc *SessionContext) AddFocusedFile(filepath string) {
 	// Remove if already exists
@@ -81,15 +162,20 @@ func (sc *SessionContext) ClearFocusedFiles() {
 	sc.UpdatedAt = time.Now()
 }
 
-// SetCurrentTask sets the current task
+// SetCurrentTask sets the current task and starts tracking the files it changes
 func (sc *SessionContext) SetCurrentTask(task string) {
 	sc.CurrentTask = task
+	sc.TaskFiles = nil
 	sc.UpdatedAt = time.Now()
 }
 
-// CompleteCurrentTask marks the current task as completed
+// CompleteCurrentTask marks the current task as completed. When filesChanged
+// is empty, the files recorded while the task was current are used.
 func (sc *SessionContext) CompleteCurrentTask(filesChanged []string) {
 	if sc.CurrentTask != "" {
+		if len(filesChanged) == 0 {
+			filesChanged = sc.TaskFiles
+		}
 		completedTask := CompletedTask{
 			Description:  sc.CurrentTask,
 			CompletedAt:  time.Now(),
@@ -97,6 +183,7 @@ func (sc *SessionContext) CompleteCurrentTask(filesChanged []string) {
 		}
 		sc.TaskHistory = append(sc.TaskHistory, completedTask)
 		sc.CurrentTask = ""
+		sc.TaskFiles = nil
 		sc.UpdatedAt = time.Now()
 	}
 }
@@ -139,6 +226,13 @@ func (sc *SessionContext) GetContextSummary() string {
 	
 	if sc.CurrentTask != "" {
 		summary.WriteString(fmt.Sprintf("Current Task: %s\n", sc.CurrentTask))
+		if len(sc.TaskFiles) > 0 {
+			summary.WriteString(fmt.Sprintf("  Files changed: %s\n", strings.Join(sc.TaskFiles, ", ")))
+		}
+	}
+
+	if len(sc.Todos) > 0 {
+		summary.WriteString(sc.FormatTodos() + "\n")
 	}
 	
 	if len(sc.FocusedFiles) > 0 {
@@ -172,6 +266,9 @@ func (sc *SessionContext) GetContextSummary() string {
 		summary.WriteString(fmt.Sprintf("  Last: %s (completed %s)\n", 
 			lastTask.Description, 
 			lastTask.CompletedAt.Format("15:04")))
+		if len(lastTask.FilesChanged) > 0 {
+			summary.WriteString(fmt.Sprintf("  Files changed: %s\n", strings.Join(lastTask.FilesChanged, ", ")))
+		}
 	}
 	
 
@@ -206,14 +303,30 @@ func LoadFromFile(filename string) (*SessionContext, error) {
 
 // Helpers
 
+// findProjectRoot returns the enclosing repository root so sub-projects of a
+// monorepo share one root, falling back to the nearest build file outside a
+// repository. The search stops below the home directory, so a dotfiles
+// repository in $HOME doesn't make it the root of every project.
 func findProjectRoot(startDir string) string {
+	home, _ := os.UserHomeDir()
+	nearest := ""
 	dir := startDir
 	for {
+		if dir == home && dir != startDir {
+			break
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+
 		// Check for common project root indicators
-		indicators := []string{".git", "go.mod", "package.json", "Cargo.toml", "pyproject.toml", "requirements.txt"}
-		for _, indicator := range indicators {
-			if _, err := os.Stat(filepath.Join(dir, indicator)); err == nil {
-				return dir
+		if nearest == "" {
+			indicators := []string{"go.work", "go.mod", "package.json", "Cargo.toml", "pyproject.toml", "requirements.txt"}
+			for _, indicator := range indicators {
+				if _, err := os.Stat(filepath.Join(dir, indicator)); err == nil {
+					nearest = dir
+					break
+				}
 			}
 		}
 		
@@ -223,6 +336,9 @@ func findProjectRoot(startDir string) string {
 		}
 		dir = parent
 	}
+	if nearest != "" {
+		return nearest
+	}
 	return startDir // Fallback to start directory
 }
 
@@ -230,38 +346,13 @@ func generateSessionID() string {
 	return fmt.Sprintf("session_%d", time.Now().Unix())
 }
 
-// DetectProjectType detects the project type based on files in the project root
+// DetectProjectType scans the project root for languages and sub-projects and
+// records the root's primary type
 func (sc *SessionContext) DetectProjectType() {
 	if sc.ProjectRoot == "" {
 		return
 	}
-	
-	// Check for Go project
-	if _, err := os.Stat(filepath.Join(sc.ProjectRoot, "go.mod")); err == nil {
-		sc.ProjectType = "go"
-		return
-	}
-	
-	// Check for Node.js project
-	if _, err := os.Stat(filepath.Join(sc.ProjectRoot, "package.json")); err == nil {
-		sc.ProjectType = "nodejs"
-		return
-	}
-	
-	// Check for Python project
-	indicators := []string{"requirements.txt", "pyproject.toml", "setup.py"}
-	for _, indicator := range indicators {
-		if _, err := os.Stat(filepath.Join(sc.ProjectRoot, indicator)); err == nil {
-			sc.ProjectType = "python"
-			return
-		}
-	}
-	
-	// Check for Rust project
-	if _, err := os.Stat(filepath.Join(sc.ProjectRoot, "Cargo.toml")); err == nil {
-		sc.ProjectType = "rust"
-		return
-	}
-	
-	sc.ProjectType = "unknown"
+
+	sc.Layout = project.Detect(sc.ProjectRoot)
+	sc.ProjectType = sc.Layout.PrimaryType()
 }
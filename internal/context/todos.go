@@ -0,0 +1,139 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Todo item statuses
+const (
+	TodoPending    = "pending"
+	TodoInProgress = "in_progress"
+	TodoCompleted  = "completed"
+)
+
+// TodoItem is one step of the model's checklist for multi-step work
+type TodoItem struct {
+	ID           string   `json:"id"`
+	Content      string   `json:"content"`
+	Status       string   `json:"status"`
+	FilesChanged []string `json:"files_changed,omitempty"`
+}
+
+// SetTodos replaces the checklist. Items without an ID are numbered, files
+// already recorded for an item are carried over by ID, and at most one item
+// may be in progress.
+func (sc *SessionContext) SetTodos(items []TodoItem) error {
+	previous := make(map[string]TodoItem, len(sc.Todos))
+	for _, item := range sc.Todos {
+		previous[item.ID] = item
+	}
+
+	todos := make([]TodoItem, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	inProgress := 0
+	for i, item := range items {
+		item.Content = strings.TrimSpace(item.Content)
+		if item.Content == "" {
+			return fmt.Errorf("todo %d has no content", i+1)
+		}
+
+		switch item.Status {
+		case "":
+			item.Status = TodoPending
+		case TodoPending, TodoCompleted:
+		case TodoInProgress:
+			inProgress++
+		default:
+			return fmt.Errorf("todo %d has invalid status %q (use %s, %s or %s)", i+1, item.Status, TodoPending, TodoInProgress, TodoCompleted)
+		}
+
+		if item.ID == "" {
+			item.ID = fmt.Sprintf("%d", i+1)
+		}
+		if seen[item.ID] {
+			return fmt.Errorf("duplicate todo ID %s", item.ID)
+		}
+		seen[item.ID] = true
+
+		if len(item.FilesChanged) == 0 {
+			item.FilesChanged = previous[item.ID].FilesChanged
+		}
+		todos = append(todos, item)
+	}
+
+	if inProgress > 1 {
+		return fmt.Errorf("%d todos are in progress; work on one at a time", inProgress)
+	}
+
+	sc.Todos = todos
+	sc.UpdatedAt = time.Now()
+	return nil
+}
+
+// RecordFileChange notes a file modified during the current task and by the
+// todo currently in progress
+func (sc *SessionContext) RecordFileChange(path string) {
+	sc.TaskFiles = appendUnique(sc.TaskFiles, path)
+	for i := range sc.Todos {
+		if sc.Todos[i].Status == TodoInProgress {
+			sc.Todos[i].FilesChanged = appendUnique(sc.Todos[i].FilesChanged, path)
+		}
+	}
+	sc.UpdatedAt = time.Now()
+}
+
+// TodosComplete reports whether there is a checklist and every item is done
+func (sc *SessionContext) TodosComplete() bool {
+	if len(sc.Todos) == 0 {
+		return false
+	}
+	for _, item := range sc.Todos {
+		if item.Status != TodoCompleted {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatTodos renders the checklist, one item per line
+func (sc *SessionContext) FormatTodos() string {
+	if len(sc.Todos) == 0 {
+		return "No todos"
+	}
+
+	done := 0
+	for _, item := range sc.Todos {
+		if item.Status == TodoCompleted {
+			done++
+		}
+	}
+
+	var list strings.Builder
+	list.WriteString(fmt.Sprintf("Todo list (%d/%d completed):\n", done, len(sc.Todos)))
+	for _, item := range sc.Todos {
+		marker := "[ ]"
+		switch item.Status {
+		case TodoInProgress:
+			marker = "[>]"
+		case TodoCompleted:
+			marker = "[x]"
+		}
+		list.WriteString(fmt.Sprintf("%s %s. %s", marker, item.ID, item.Content))
+		if len(item.FilesChanged) > 0 {
+			list.WriteString(fmt.Sprintf(" (changed: %s)", strings.Join(item.FilesChanged, ", ")))
+		}
+		list.WriteString("\n")
+	}
+	return strings.TrimRight(list.String(), "\n")
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -13,7 +13,11 @@ type ContextWindow struct {
 	SummaryTokens    int                    `json:"summary_tokens"`    // Tokens used for conversation summary
 	Messages         []ConversationMessage  `json:"messages"`
 	ConversationSummary string             `json:"conversation_summary"`
-	ImportantMessages []ConversationMessage `json:"important_messages"` // Always keep these
+	PinnedTokenBudget int                   `json:"pinned_token_budget"` // Separate budget for pinned messages
+	NextMessageID    int                    `json:"next_message_id"`
+	Turn             int                    `json:"turn"`
+	Compaction       CompactionPolicy       `json:"-"`
+	CompactionStats  CompactionStats        `json:"compaction_stats"`
 }
 
 // ConversationMessage represents a message with metadata
@@ -22,8 +26,10 @@ type ConversationMessage struct {
 	Content   string `json:"content"`
 	Tokens    int    `json:"tokens"`
 	Timestamp int64  `json:"timestamp"`
-	Important bool   `json:"important"` // Mark as important to preserve
+	Pinned    bool   `json:"pinned"` // Pinned messages survive trimming and /clear
 	MessageID string `json:"message_id"`
+	Turn        int          `json:"turn,omitempty"`         // User turn the message was added in
+	ToolOutputs []ToolOutput `json:"tool_outputs,omitempty"` // Tool results that can be compacted
 }
 
 // NewContextWindow creates a new context window manager
@@ -32,8 +38,9 @@ func NewContextWindow(maxTokens int) *ContextWindow {
 		MaxTokens:         maxTokens,
 		ReservedTokens:    2000, // Reserve for system prompt and tools
 		SummaryTokens:     500,  // Reserve for conversation summary
+		PinnedTokenBudget: 4000,
 		Messages:          []ConversationMessage{},
-		ImportantMessages: []ConversationMessage{},
+		Compaction:        NewCompactionPolicy(3),
 	}
 }
 
@@ -42,20 +49,17 @@ func EstimateTokens(text string) int {
 	return len(text) / 4
 }
 
-// AddMessage adds a message to the context window
-func (cw *ContextWindow) AddMessage(role, content string, important bool) {
+// AddMessage adds a message to the context window, pinning it if requested
+// and the pinned budget allows
+func (cw *ContextWindow) AddMessage(role, content string, pin bool) {
 	tokens := EstimateTokens(content)
 	message := ConversationMessage{
 		Role:      role,
 		Content:   content,
 		Tokens:    tokens,
 		Timestamp: getCurrentTimestamp(),
-		Important: important,
-		MessageID: generateMessageID(),
-	}
-	
-	if important {
-		cw.ImportantMessages = append(cw.ImportantMessages, message)
+		Pinned:    pin && cw.pinnedTokens()+tokens <= cw.PinnedTokenBudget,
+		MessageID: cw.newMessageID(),
 	}
 	
 	cw.Messages = append(cw.Messages, message)
@@ -64,8 +68,9 @@ func (cw *ContextWindow) AddMessage(role, content string, important bool) {
 
 // trimIfNeeded trims the conversation if it exceeds token limits
 func (cw *ContextWindow) trimIfNeeded() {
-	availableTokens := cw.MaxTokens - cw.ReservedTokens - cw.SummaryTokens
-	currentTokens := cw.calculateCurrentTokens()
+	// Pinned messages have their own budget, so they don't count here
+	availableTokens := cw.MaxTokens - cw.ReservedTokens - cw.SummaryTokens - cw.PinnedTokenBudget
+	currentTokens := cw.calculateCurrentTokens() - cw.pinnedTokens()
 	
 	if currentTokens <= availableTokens {
 		return // No trimming needed
@@ -75,13 +80,14 @@ func (cw *ContextWindow) trimIfNeeded() {
 	messagesToSummarize := []ConversationMessage{}
 	messagesToKeep := []ConversationMessage{}
 	
-	// Always keep important messages and recent messages
+	// Always keep the system prompt, pinned and recent messages
 	recentCount := 10 // Keep last 10 messages
 	totalMessages := len(cw.Messages)
+	promptIndex := cw.systemPromptIndex()
 	
 	for i, msg := range cw.Messages {
 		isRecent := i >= totalMessages-recentCount
-		if msg.Important || isRecent {
+		if i == promptIndex || msg.Pinned || isRecent {
 			messagesToKeep = append(messagesToKeep, msg)
 		} else {
 			messagesToSummarize = append(messagesToSummarize, msg)
@@ -193,17 +199,21 @@ func (cw *ContextWindow) GetContextStats() string {
 - Max tokens: %d
 - Reserved tokens: %d
 - Messages: %d
-- Important messages: %d
+- Pinned messages: %d (~%d of %d pinned tokens)
 - Has summary: %v
-- Usage: %.1f%%`,
+- Usage: %.1f%%
+%s`,
 		currentTokens,
 		availableTokens,
 		cw.MaxTokens,
 		cw.ReservedTokens,
 		len(cw.Messages),
-		len(cw.ImportantMessages),
+		cw.pinnedCount(),
+		cw.pinnedTokens(),
+		cw.PinnedTokenBudget,
 		cw.ConversationSummary != "",
-		cw.GetUsagePercentage())
+		cw.GetUsagePercentage(),
+		cw.compactionSummary())
 }
 
 // returns the context window usage as a percentage
@@ -216,20 +226,44 @@ func (cw *ContextWindow) GetUsagePercentage() float64 {
 	return float64(currentTokens) / float64(availableTokens) * 100
 }
 
-// marks a recent message as important
-func (cw *ContextWindow) MarkMessageImportant(messageID string) {
-	for i := range cw.Messages {
-		if cw.Messages[i].MessageID == messageID {
-			cw.Messages[i].Important = true
-			cw.ImportantMessages = append(cw.ImportantMessages, cw.Messages[i])
-			break
+// UpdateSystemMessage replaces the content of the first system message,
+// reporting whether one was found
+func (cw *ContextWindow) UpdateSystemMessage(content string) bool {
+	for i, msg := range cw.Messages {
+		if msg.Role != "system" {
+			continue
 		}
+
+		cw.Messages[i].Content = content
+		cw.Messages[i].Tokens = EstimateTokens(content)
+		return true
 	}
+	return false
 }
 
-// ClearConversation clears all messages but keeps important ones
+// systemPromptIndex returns the index of the main system prompt, the first
+// system message, or -1. Later system messages are transient notes such as
+// hook output and verification feedback.
+func (cw *ContextWindow) systemPromptIndex() int {
+	for i, msg := range cw.Messages {
+		if msg.Role == "system" {
+			return i
+		}
+	}
+	return -1
+}
+
+// ClearConversation clears all messages but keeps the system prompt and
+// pinned messages
 func (cw *ContextWindow) ClearConversation() {
-	cw.Messages = cw.ImportantMessages
+	kept := []ConversationMessage{}
+	promptIndex := cw.systemPromptIndex()
+	for i, msg := range cw.Messages {
+		if i == promptIndex || msg.Pinned {
+			kept = append(kept, msg)
+		}
+	}
+	cw.Messages = kept
 	cw.ConversationSummary = ""
 }
 
@@ -245,6 +279,8 @@ func getCurrentTimestamp() int64 {
 	return time.Now().Unix()
 }
 
-func generateMessageID() string {
-	return fmt.Sprintf("msg_%d_%d", time.Now().Unix(), time.Now().UnixNano()%1000)
+// newMessageID returns a short sequential ID that is easy to type in /pin
+func (cw *ContextWindow) newMessageID() string {
+	cw.NextMessageID++
+	return fmt.Sprintf("m%d", cw.NextMessageID)
 }
@@ -0,0 +1,153 @@
+// Package git wraps the git command line for the agent and its tools.
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Run runs a git subcommand in dir and returns its stdout
+func Run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	if dir != "" {
+		cmd.Dir = dir
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+	}
+
+	return stdout.String(), nil
+}
+
+// TopLevel returns the root of the repository containing dir
+func TopLevel(dir string) (string, error) {
+	out, err := Run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// IsRepo reports whether dir is inside a git work tree
+func IsRepo(dir string) bool {
+	out, err := Run(dir, "rev-parse", "--is-inside-work-tree")
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+// Head returns the full hash of HEAD
+func Head(dir string) (string, error) {
+	out, err := Run(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// CurrentBranch returns the checked out branch name, or "HEAD" when detached
+func CurrentBranch(dir string) (string, error) {
+	out, err := Run(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// FilesInRepo returns the subset of paths that live inside the repository at root
+func FilesInRepo(root string, paths []string) []string {
+	var inside []string
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		inside = append(inside, abs)
+	}
+	return inside
+}
+
+// StageFiles stages the given paths and reports whether any of them differ from HEAD
+func StageFiles(dir string, paths []string) (bool, error) {
+	if len(paths) == 0 {
+		return false, nil
+	}
+
+	addArgs := append([]string{"add", "-A", "--"}, paths...)
+	if _, err := Run(dir, addArgs...); err != nil {
+		return false, err
+	}
+
+	diffArgs := append([]string{"diff", "--cached", "--name-only", "--"}, paths...)
+	staged, err := Run(dir, diffArgs...)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(staged) != "", nil
+}
+
+// CommitFiles commits only the given paths, leaving anything else in the index alone,
+// and returns the new HEAD hash
+func CommitFiles(dir, message string, paths []string) (string, error) {
+	commitArgs := append([]string{"commit", "-m", message, "--"}, paths...)
+	if _, err := Run(dir, commitArgs...); err != nil {
+		return "", err
+	}
+	return Head(dir)
+}
+
+// IsAncestor reports whether ancestor is rev or one of its ancestors
+func IsAncestor(dir, ancestor, rev string) bool {
+	_, err := Run(dir, "merge-base", "--is-ancestor", ancestor, rev)
+	return err == nil
+}
+
+// BranchExists reports whether a local branch with the given name exists
+func BranchExists(dir, branch string) bool {
+	_, err := Run(dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+// DirtyFiles returns the absolute paths of the files under dir that git
+// status lists: changed, staged, deleted and untracked files, but not
+// ignored ones
+func DirtyFiles(dir string) ([]string, error) {
+	root, err := TopLevel(dir)
+	if err != nil {
+		return nil, err
+	}
+	out, err := Run(dir, "status", "--porcelain", "-z", "--untracked-files=all", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		files = append(files, filepath.Join(root, entry[3:]))
+		// A rename or copy is followed by its source
+		if (entry[0] == 'R' || entry[0] == 'C') && i+1 < len(entries) {
+			i++
+			files = append(files, filepath.Join(root, entries[i]))
+		}
+	}
+	return files, nil
+}
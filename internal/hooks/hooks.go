@@ -0,0 +1,232 @@
+// Package hooks runs user-configured shell commands at points in the agent's
+// lifecycle. Each command receives the event as JSON on stdin and can block
+// the action, replace tool arguments or add context through its exit code and
+// stdout.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Event names a point in the lifecycle where hooks run
+type Event string
+
+const (
+	SessionStart     Event = "session_start"
+	UserPromptSubmit Event = "user_prompt_submit"
+	PreToolUse       Event = "pre_tool_use"
+	PostToolUse      Event = "post_tool_use"
+	TurnEnd          Event = "turn_end"
+)
+
+// blockExitCode is the exit status a hook uses to block the action
+const blockExitCode = 2
+
+// defaultTimeout applies to hooks without a timeout
+const defaultTimeout = 60 * time.Second
+
+// maxOutput bounds how much of a hook's stdout or stderr is used
+const maxOutput = 16 * 1024
+
+// Hook is one configured command
+type Hook struct {
+	Matcher string // regular expression on the tool name; empty matches every tool
+	Command string
+	Timeout time.Duration
+
+	matcher *regexp.Regexp
+}
+
+// Input is the JSON a hook receives on stdin
+type Input struct {
+	Event       Event                  `json:"event"`
+	SessionID   string                 `json:"session_id"`
+	ProjectRoot string                 `json:"project_root"`
+	WorkingDir  string                 `json:"cwd"`
+	ToolName    string                 `json:"tool_name,omitempty"`
+	ToolArgs    map[string]interface{} `json:"tool_args,omitempty"`
+	ToolResult  string                 `json:"tool_result,omitempty"`
+	ToolError   string                 `json:"tool_error,omitempty"`
+	Prompt      string                 `json:"prompt,omitempty"`
+	Response    string                 `json:"response,omitempty"`
+}
+
+// output is the optional JSON a hook prints on stdout
+type output struct {
+	Decision string                 `json:"decision"`
+	Reason   string                 `json:"reason"`
+	Message  string                 `json:"message"`
+	ToolArgs map[string]interface{} `json:"tool_args"`
+}
+
+// Result combines the outcome of every hook run for an event
+type Result struct {
+	Blocked  bool
+	Reason   string
+	ToolArgs map[string]interface{} // replacement arguments, when a hook changed them
+	Messages []string               // text the hooks want added to the context
+	Errors   []string               // hooks that failed without blocking
+}
+
+// Runner holds the configured hooks. It keeps no state between runs, so it
+// is safe to use from several goroutines.
+type Runner struct {
+	hooks map[Event][]Hook
+}
+
+// NewRunner validates hooks and creates a runner for them
+func NewRunner(hooks map[Event][]Hook) (*Runner, error) {
+	runner := &Runner{hooks: make(map[Event][]Hook)}
+	for event, list := range hooks {
+		for _, hook := range list {
+			if strings.TrimSpace(hook.Command) == "" {
+				continue
+			}
+			if hook.Matcher != "" {
+				matcher, err := regexp.Compile("^(?:" + hook.Matcher + ")$")
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s hook matcher %q: %w", event, hook.Matcher, err)
+				}
+				hook.matcher = matcher
+			}
+			if hook.Timeout <= 0 {
+				hook.Timeout = defaultTimeout
+			}
+			runner.hooks[event] = append(runner.hooks[event], hook)
+		}
+	}
+	return runner, nil
+}
+
+// Has reports whether any hook is configured for event
+func (r *Runner) Has(event Event) bool {
+	return r != nil && len(r.hooks[event]) > 0
+}
+
+// Run executes the hooks for input.Event in order. A blocking hook stops the
+// rest; arguments replaced by one hook are passed on to the next.
+func (r *Runner) Run(input Input) Result {
+	var result Result
+	if r == nil {
+		return result
+	}
+
+	for _, hook := range r.hooks[input.Event] {
+		if hook.matcher != nil && !hook.matcher.MatchString(input.ToolName) {
+			continue
+		}
+
+		out, err := r.runHook(hook, input)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s hook `%s`: %v", input.Event, hook.Command, err))
+			continue
+		}
+
+		if out.Message != "" {
+			result.Messages = append(result.Messages, out.Message)
+		}
+		if out.ToolArgs != nil && input.Event == PreToolUse {
+			result.ToolArgs = out.ToolArgs
+			input.ToolArgs = out.ToolArgs
+		}
+		if out.Decision == "block" {
+			result.Blocked = true
+			result.Reason = out.Reason
+			if result.Reason == "" {
+				result.Reason = fmt.Sprintf("blocked by hook `%s`", hook.Command)
+			}
+			break
+		}
+	}
+	return result
+}
+
+// runHook runs one command from the agent's working directory, where relative
+// tool paths resolve, and interprets its exit code and output
+func (r *Runner) runHook(hook Hook, input Input) (output, error) {
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return output{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hook.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook.Command)
+	cmd.Dir = input.WorkingDir
+	cmd.Env = append(os.Environ(), environment(input)...)
+	cmd.Stdin = bytes.NewReader(payload)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait on children of the shell that keep the output pipes open
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return output{}, fmt.Errorf("timed out after %s", hook.Timeout)
+	}
+
+	stdoutText := strings.TrimSpace(truncate(stdout.String()))
+	stderrText := strings.TrimSpace(truncate(stderr.String()))
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if exitErr.ExitCode() == blockExitCode {
+			reason := stderrText
+			if reason == "" {
+				reason = stdoutText
+			}
+			return output{Decision: "block", Reason: reason}, nil
+		}
+		detail := stderrText
+		if detail == "" {
+			detail = stdoutText
+		}
+		return output{}, fmt.Errorf("exit status %d: %s", exitErr.ExitCode(), detail)
+	}
+	if err != nil {
+		return output{}, err
+	}
+
+	// Plain text is context to add; JSON can also block or change arguments
+	var out output
+	if strings.HasPrefix(stdoutText, "{") {
+		if err := json.Unmarshal([]byte(stdoutText), &out); err != nil {
+			return output{}, fmt.Errorf("invalid JSON output: %v", err)
+		}
+		return out, nil
+	}
+	out.Message = stdoutText
+	return out, nil
+}
+
+// environment exposes the most useful input fields as variables, so simple
+// hooks don't need to parse JSON
+func environment(input Input) []string {
+	env := []string{
+		"AGENT_HOOK_EVENT=" + string(input.Event),
+		"AGENT_PROJECT_ROOT=" + input.ProjectRoot,
+	}
+	if input.ToolName != "" {
+		env = append(env, "AGENT_TOOL_NAME="+input.ToolName)
+	}
+	if path, ok := input.ToolArgs["path"].(string); ok {
+		env = append(env, "AGENT_FILE_PATH="+path)
+	}
+	return env
+}
+
+func truncate(s string) string {
+	if len(s) > maxOutput {
+		return s[:maxOutput]
+	}
+	return s
+}
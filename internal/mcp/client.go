@@ -0,0 +1,245 @@
+// Package mcp speaks the Model Context Protocol. The client connects to tool
+// servers over stdio or streamable HTTP and adapts their tools to the
+// agent's tool interface; the server offers a tool registry over stdio.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout applies to servers configured without a timeout
+const DefaultTimeout = 60 * time.Second
+
+// ServerConfig describes how to reach a server: a command to start for the
+// stdio transport, or a URL for the streamable HTTP transport
+type ServerConfig struct {
+	Name    string
+	Command string
+	Args    []string
+	Env     []string // KEY=VALUE entries added to the environment; $VARS are expanded
+	Dir     string   // working directory of the command
+	URL     string
+	Headers map[string]string // sent with every HTTP request; $VARS are expanded
+	Timeout time.Duration     // per request
+
+	// Trusted lets the server's read-only annotations stand; ReadOnlyTools
+	// names tools to treat as read-only regardless
+	Trusted       bool
+	ReadOnlyTools []string
+}
+
+// Transport names the transport the configuration selects
+func (c ServerConfig) Transport() string {
+	if c.URL != "" {
+		return "http"
+	}
+	return "stdio"
+}
+
+// Target describes the command or URL
+func (c ServerConfig) Target() string {
+	if c.URL != "" {
+		return c.URL
+	}
+	return strings.TrimSpace(c.Command + " " + strings.Join(c.Args, " "))
+}
+
+// Status describes a configured server and the tools registered from it
+type Status struct {
+	Name      string
+	Transport string
+	Target    string
+	Server    string // name and version the server reported
+	Error     string // why connecting failed
+	Tools     []ToolStatus
+}
+
+// ToolStatus is one registered tool of a server
+type ToolStatus struct {
+	Name        string
+	Description string
+	ReadOnly    bool
+}
+
+// transport carries JSON-RPC messages to a server
+type transport interface {
+	request(ctx context.Context, req *message) (*message, error)
+	notify(ctx context.Context, msg *message) error
+	close() error
+}
+
+// Client is a connection to one server. It is safe to use from several
+// goroutines.
+type Client struct {
+	config    ServerConfig
+	transport transport
+	nextID    atomic.Int64
+	server    implementation
+	tools     []Tool
+}
+
+// Connect starts or contacts the server, initializes the session and lists
+// its tools
+func Connect(cfg ServerConfig) (*Client, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("every server needs a name")
+	}
+	if (cfg.Command == "") == (cfg.URL == "") {
+		return nil, fmt.Errorf("server %s needs either a command or a url", cfg.Name)
+	}
+
+	c := &Client{config: cfg}
+	if cfg.URL != "" {
+		c.transport = newHTTPTransport(cfg)
+	} else {
+		t, err := startStdio(cfg)
+		if err != nil {
+			return nil, err
+		}
+		c.transport = t
+	}
+
+	if err := c.initialize(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) initialize() error {
+	var result initializeResult
+	err := c.call("initialize", initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]interface{}{},
+		ClientInfo:      self,
+	}, &result)
+	if err != nil {
+		return fmt.Errorf("initialize failed: %w", err)
+	}
+	c.server = result.ServerInfo
+	if t, ok := c.transport.(*httpTransport); ok {
+		t.setVersion(result.ProtocolVersion)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout)
+	defer cancel()
+	if err := c.transport.notify(ctx, &message{JSONRPC: "2.0", Method: "notifications/initialized"}); err != nil {
+		return fmt.Errorf("initialize failed: %w", err)
+	}
+
+	cursor := ""
+	for {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var page listToolsResult
+		if err := c.call("tools/list", params, &page); err != nil {
+			return fmt.Errorf("listing tools failed: %w", err)
+		}
+		c.tools = append(c.tools, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// Name returns the configured name of the server
+func (c *Client) Name() string {
+	return c.config.Name
+}
+
+// ServerInfo returns the name and version the server reported
+func (c *Client) ServerInfo() string {
+	return strings.TrimSpace(c.server.Name + " " + c.server.Version)
+}
+
+// Tools returns the tools the server offered when connecting
+func (c *Client) Tools() []Tool {
+	return c.tools
+}
+
+// CallTool runs a tool and returns its text output. A result the server
+// marks as an error is returned as an error.
+func (c *Client) CallTool(name string, args map[string]interface{}) (string, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	var result callToolResult
+	if err := c.call("tools/call", callToolParams{Name: name, Arguments: args}, &result); err != nil {
+		return "", err
+	}
+
+	text := formatContent(result)
+	if result.IsError {
+		if text == "" {
+			text = "tool reported an error"
+		}
+		return "", fmt.Errorf("%s", text)
+	}
+	return text, nil
+}
+
+// Close ends the session and stops a stdio server
+func (c *Client) Close() error {
+	return c.transport.close()
+}
+
+// call sends a request and decodes the result
+func (c *Client) call(method string, params interface{}, result interface{}) error {
+	req, err := newRequest(c.nextID.Add(1), method, params)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout)
+	defer cancel()
+
+	resp, err := c.transport.request(ctx, req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s timed out after %s", method, c.config.Timeout)
+		}
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+// formatContent joins the content of a tool result into text
+func formatContent(result callToolResult) string {
+	var parts []string
+	for _, item := range result.Content {
+		switch item.Type {
+		case "text":
+			parts = append(parts, item.Text)
+		case "image", "audio":
+			parts = append(parts, fmt.Sprintf("[%s: %s]", item.Type, item.MimeType))
+		case "resource":
+			if item.Resource != nil && item.Resource.Text != "" {
+				parts = append(parts, item.Resource.Text)
+			} else if item.Resource != nil {
+				parts = append(parts, fmt.Sprintf("[resource: %s]", item.Resource.URI))
+			}
+		case "resource_link":
+			parts = append(parts, fmt.Sprintf("[resource link: %s]", item.URI))
+		}
+	}
+	if len(parts) == 0 && len(result.StructuredContent) > 0 {
+		return string(result.StructuredContent)
+	}
+	return strings.Join(parts, "\n")
+}
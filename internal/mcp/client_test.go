@@ -0,0 +1,306 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer is the path of the built fakeserver command
+var fakeServer string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "mcp-fakeserver")
+	if err != nil {
+		panic(err)
+	}
+	fakeServer = filepath.Join(dir, "fakeserver")
+	build := exec.Command("go", "build", "-o", fakeServer, "./testdata/fakeserver")
+	if out, err := build.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		panic("building fakeserver: " + err.Error() + "\n" + string(out))
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// connect starts the fake server over stdio with the given flags
+func connect(t *testing.T, args ...string) *Client {
+	t.Helper()
+	client, err := Connect(ServerConfig{Name: "fake", Command: fakeServer, Args: args, Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func toolNames(client *Client) []string {
+	var names []string
+	for _, tool := range client.Tools() {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestConnect(t *testing.T) {
+	client := connect(t)
+	if got := client.ServerInfo(); got != "fakeserver 0.1" {
+		t.Errorf("ServerInfo = %q, want %q", got, "fakeserver 0.1")
+	}
+	want := "echo add fail sleep exit"
+	if got := strings.Join(toolNames(client), " "); got != want {
+		t.Errorf("tools = %q, want %q", got, want)
+	}
+}
+
+func TestConnectPagesTools(t *testing.T) {
+	for _, size := range []string{"1", "2", "5"} {
+		client := connect(t, "-page-size", size)
+		want := "echo add fail sleep exit"
+		if got := strings.Join(toolNames(client), " "); got != want {
+			t.Errorf("page size %s: tools = %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestCallTool(t *testing.T) {
+	client := connect(t)
+
+	out, err := client.CallTool("echo", map[string]interface{}{"text": "hello"})
+	if err != nil || out != "hello" {
+		t.Errorf("echo = %q, %v; want %q", out, err, "hello")
+	}
+	out, err = client.CallTool("add", map[string]interface{}{"a": 2, "b": 3.5})
+	if err != nil || out != "5.5" {
+		t.Errorf("add = %q, %v; want %q", out, err, "5.5")
+	}
+}
+
+func TestCallToolError(t *testing.T) {
+	client := connect(t)
+
+	out, err := client.CallTool("fail", map[string]interface{}{"message": "oops"})
+	if err == nil {
+		t.Fatalf("fail = %q, want an error", out)
+	}
+	if want := "failed as requested: oops"; err.Error() != want {
+		t.Errorf("fail error = %q, want %q", err, want)
+	}
+
+	// A tool error leaves the session usable
+	if out, err := client.CallTool("echo", map[string]interface{}{"text": "still here"}); err != nil || out != "still here" {
+		t.Errorf("echo after error = %q, %v", out, err)
+	}
+}
+
+func TestServerExit(t *testing.T) {
+	client := connect(t)
+
+	_, err := client.CallTool("exit", nil)
+	if err == nil || !strings.Contains(err.Error(), "server exited") {
+		t.Fatalf("exit error = %v, want server exited", err)
+	}
+	_, err = client.CallTool("echo", map[string]interface{}{"text": "hello"})
+	if err == nil || !strings.Contains(err.Error(), "server exited") {
+		t.Errorf("call after exit error = %v, want server exited", err)
+	}
+}
+
+// httpServer is a streamable HTTP server offering the echo tool. It records
+// the requests it receives and refuses those without its session ID.
+type httpServer struct {
+	sse bool // reply with event streams instead of JSON
+
+	mu       sync.Mutex
+	requests []string // method and session ID of every request
+}
+
+const testSessionID = "test-session"
+
+func (s *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var msg message
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	name := msg.Method
+	switch {
+	case r.Method == http.MethodDelete:
+		name = "DELETE"
+	case msg.isResponse():
+		name = "response " + string(msg.ID)
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, name+" "+r.Header.Get("Mcp-Session-Id"))
+	s.mu.Unlock()
+
+	if msg.Method == "initialize" {
+		w.Header().Set("Mcp-Session-Id", testSessionID)
+	} else if r.Header.Get("Mcp-Session-Id") != testSessionID {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	if r.Method == http.MethodDelete || len(msg.ID) == 0 || msg.isResponse() {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	reply := &message{JSONRPC: "2.0", ID: msg.ID}
+	switch msg.Method {
+	case "initialize":
+		reply.Result = json.RawMessage(`{"protocolVersion": "2025-06-18", "capabilities": {"tools": {}}, "serverInfo": {"name": "httpserver", "version": "0.1"}}`)
+	case "tools/list":
+		reply.Result = json.RawMessage(`{"tools": [{"name": "echo", "inputSchema": {"type": "object"}}]}`)
+	case "tools/call":
+		var call struct {
+			Arguments struct {
+				Text string `json:"text"`
+			} `json:"arguments"`
+		}
+		json.Unmarshal(msg.Params, &call)
+		text, _ := json.Marshal(call.Arguments.Text)
+		reply.Result = json.RawMessage(fmt.Sprintf(`{"content": [{"type": "text", "text": %s}]}`, text))
+	default:
+		reply.Error = &rpcError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+	data, _ := json.Marshal(reply)
+
+	if !s.sse {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+		return
+	}
+	// Precede the response with a notification and a request the client
+	// must answer
+	w.Header().Set("Content-Type", "text/event-stream")
+	fmt.Fprint(w, "event: message\ndata: {\"jsonrpc\": \"2.0\", \"method\": \"notifications/message\", \"params\": {\"data\": \"working\"}}\n\n")
+	fmt.Fprint(w, "event: message\ndata: {\"jsonrpc\": \"2.0\", \"id\": \"server-ping\", \"method\": \"ping\"}\n\n")
+	fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+}
+
+func (s *httpServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// connectHTTP connects to server over streamable HTTP
+func connectHTTP(t *testing.T, server *httpServer) *Client {
+	t.Helper()
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client, err := Connect(ServerConfig{Name: "http", URL: ts.URL, Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	return client
+}
+
+func TestHTTPTransport(t *testing.T) {
+	for _, sse := range []bool{false, true} {
+		server := &httpServer{sse: sse}
+		client := connectHTTP(t, server)
+
+		if got := client.ServerInfo(); got != "httpserver 0.1" {
+			t.Errorf("sse %v: ServerInfo = %q, want %q", sse, got, "httpserver 0.1")
+		}
+		if got := strings.Join(toolNames(client), " "); got != "echo" {
+			t.Errorf("sse %v: tools = %q, want %q", sse, got, "echo")
+		}
+		out, err := client.CallTool("echo", map[string]interface{}{"text": "hello"})
+		if err != nil || out != "hello" {
+			t.Errorf("sse %v: echo = %q, %v; want %q", sse, out, err, "hello")
+		}
+		if err := client.Close(); err != nil {
+			t.Errorf("sse %v: Close: %v", sse, err)
+		}
+
+		// Every request after initialize carries the session ID, including
+		// the answers to the server's pings and the DELETE ending the session
+		received := server.received()
+		if len(received) == 0 || received[0] != "initialize " {
+			t.Fatalf("sse %v: requests = %q, want initialize first without a session", sse, received)
+		}
+		for _, request := range received[1:] {
+			if !strings.HasSuffix(request, " "+testSessionID) {
+				t.Errorf("sse %v: request %q without the session ID", sse, request)
+			}
+		}
+		if last := received[len(received)-1]; !strings.HasPrefix(last, "DELETE") {
+			t.Errorf("sse %v: last request = %q, want DELETE", sse, last)
+		}
+		pings := 0
+		for _, request := range received {
+			if strings.HasPrefix(request, `response "server-ping"`) {
+				pings++
+			}
+		}
+		if want := map[bool]int{false: 0, true: 3}[sse]; pings != want {
+			t.Errorf("sse %v: answered %d server pings, want %d in %q", sse, pings, want, received)
+		}
+	}
+}
+
+func TestHTTPTransportUnknownSession(t *testing.T) {
+	server := &httpServer{}
+	client := connectHTTP(t, server)
+	defer client.Close()
+
+	client.transport.(*httpTransport).sessionID = "expired"
+	_, err := client.CallTool("echo", map[string]interface{}{"text": "hello"})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("call with an unknown session error = %v, want 404", err)
+	}
+}
+
+func TestToolName(t *testing.T) {
+	if got := ToolName("git hub", "search.code"); got != "mcp__git_hub__search_code" {
+		t.Errorf("ToolName = %q", got)
+	}
+
+	long := strings.Repeat("x", 60)
+	a, b := ToolName("server", long+"_a"), ToolName("server", long+"_b")
+	if len(a) > maxToolNameLength || len(b) > maxToolNameLength {
+		t.Errorf("names longer than %d: %q, %q", maxToolNameLength, a, b)
+	}
+	if a == b {
+		t.Errorf("long names collide: %q", a)
+	}
+}
+
+func TestRemoteToolReadOnly(t *testing.T) {
+	var hinted, plain Tool
+	json.Unmarshal([]byte(`{"name": "search", "annotations": {"readOnlyHint": true}}`), &hinted)
+	json.Unmarshal([]byte(`{"name": "lookup"}`), &plain)
+
+	tests := []struct {
+		config ServerConfig
+		tool   Tool
+		want   bool
+	}{
+		{ServerConfig{}, hinted, false},
+		{ServerConfig{Trusted: true}, hinted, true},
+		{ServerConfig{Trusted: true}, plain, false},
+		{ServerConfig{ReadOnlyTools: []string{"lookup"}}, plain, true},
+		{ServerConfig{ReadOnlyTools: []string{"lookup"}}, hinted, false},
+	}
+	for _, tt := range tests {
+		tool := NewTool(&Client{config: tt.config}, tt.tool)
+		if got := tool.ReadOnly(); got != tt.want {
+			t.Errorf("ReadOnly(%s, %+v) = %v, want %v", tt.tool.Name, tt.config, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// httpTransport talks to a server over the streamable HTTP transport: each
+// message is POSTed and the reply is either JSON or a stream of server-sent
+// events ending with the response
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu        sync.Mutex
+	sessionID string // assigned by the server when initializing
+	version   string // negotiated protocol version
+}
+
+func newHTTPTransport(cfg ServerConfig) *httpTransport {
+	headers := make(map[string]string, len(cfg.Headers))
+	for name, value := range cfg.Headers {
+		headers[name] = os.ExpandEnv(value)
+	}
+	return &httpTransport{url: cfg.URL, headers: headers, client: &http.Client{}}
+}
+
+func (t *httpTransport) request(ctx context.Context, req *message) (*message, error) {
+	resp, err := t.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		var reply message
+		if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+			return nil, fmt.Errorf("invalid response from %s: %w", t.url, err)
+		}
+		return &reply, nil
+	}
+
+	// The stream may carry requests from the server before the response
+	reader := bufio.NewReaderSize(resp.Body, 64*1024)
+	for {
+		data, err := readEvent(reader)
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("%s closed the stream without a response", t.url)
+			}
+			return nil, err
+		}
+		var msg message
+		if data == "" || json.Unmarshal([]byte(data), &msg) != nil {
+			continue
+		}
+		switch {
+		case msg.isResponse() && string(msg.ID) == string(req.ID):
+			return &msg, nil
+		case msg.Method != "" && len(msg.ID) > 0:
+			if resp, err := t.post(ctx, answerServerRequest(&msg)); err == nil {
+				resp.Body.Close()
+			}
+		}
+	}
+}
+
+func (t *httpTransport) notify(ctx context.Context, msg *message) error {
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// close ends the session on the server
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodDelete, t.url, nil)
+	if err != nil {
+		return err
+	}
+	t.setHeaders(req)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// setVersion sends the negotiated protocol version with later requests
+func (t *httpTransport) setVersion(version string) {
+	t.mu.Lock()
+	t.version = version
+	t.mu.Unlock()
+}
+
+func (t *httpTransport) post(ctx context.Context, msg *message) (*http.Response, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.setHeaders(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s %s", t.url, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if sessionID := resp.Header.Get("Mcp-Session-Id"); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) setHeaders(req *http.Request) {
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	if t.version != "" {
+		req.Header.Set("Mcp-Protocol-Version", t.version)
+	}
+}
+
+// readEvent returns the data of the next server-sent event
+func readEvent(reader *bufio.Reader) (string, error) {
+	var data []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF && len(data) > 0 {
+				return strings.Join(data, "\n"), nil
+			}
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				return strings.Join(data, "\n"), nil
+			}
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		if err == io.EOF {
+			return strings.Join(data, "\n"), nil
+		}
+	}
+}
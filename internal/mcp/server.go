@@ -0,0 +1,191 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/ttli3/go-coding-agent/internal/tools"
+)
+
+// supportedVersions are the protocol revisions the server can answer with
+var supportedVersions = map[string]bool{
+	"2025-06-18": true,
+	"2025-03-26": true,
+	"2024-11-05": true,
+}
+
+// JSON-RPC error codes used by the server
+const (
+	codeParseError    = -32700
+	codeInvalidParams = -32602
+)
+
+// Server offers the tools of a registry to MCP clients over stdio
+type Server struct {
+	registry *tools.Registry
+	permit   func(tools.Tool) bool
+
+	writeMu sync.Mutex
+	execMu  sync.Mutex // tools that change files run one at a time
+}
+
+// NewServer creates a server for the registry's tools. Only tools accepted
+// by permit are listed and run; a nil permit accepts every tool.
+func NewServer(registry *tools.Registry, permit func(tools.Tool) bool) *Server {
+	if permit == nil {
+		permit = func(tools.Tool) bool { return true }
+	}
+	return &Server{registry: registry, permit: permit}
+}
+
+// ServeStdio answers newline-delimited JSON-RPC messages from in on out
+// until in ends. Requests are handled concurrently, except that tools which
+// aren't read-only run one at a time.
+func (s *Server) ServeStdio(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	var wg sync.WaitGroup
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			s.write(out, &message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: err.Error()}})
+			continue
+		}
+		if msg.Method == "" || len(msg.ID) == 0 {
+			continue // notifications and responses need no answer
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.write(out, s.handle(&msg))
+		}()
+	}
+	wg.Wait()
+	return scanner.Err()
+}
+
+func (s *Server) write(out io.Writer, msg *message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	out.Write(append(data, '\n'))
+}
+
+// handle answers one request
+func (s *Server) handle(req *message) *message {
+	reply := &message{JSONRPC: "2.0", ID: req.ID}
+	var result interface{}
+	var err *rpcError
+	switch req.Method {
+	case "initialize":
+		result = s.initialize(req.Params)
+	case "ping":
+		result = map[string]interface{}{}
+	case "tools/list":
+		result = listToolsResult{Tools: s.listTools()}
+	case "tools/call":
+		result, err = s.callTool(req.Params)
+	default:
+		err = &rpcError{Code: codeMethodNotFound, Message: "method not supported: " + req.Method}
+	}
+
+	if err != nil {
+		reply.Error = err
+		return reply
+	}
+	data, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		reply.Error = &rpcError{Code: codeInvalidParams, Message: marshalErr.Error()}
+		return reply
+	}
+	reply.Result = data
+	return reply
+}
+
+func (s *Server) initialize(params json.RawMessage) map[string]interface{} {
+	var req initializeParams
+	json.Unmarshal(params, &req)
+	version := ProtocolVersion
+	if supportedVersions[req.ProtocolVersion] {
+		version = req.ProtocolVersion
+	}
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities":    map[string]interface{}{"tools": map[string]interface{}{"listChanged": false}},
+		"serverInfo":      self,
+	}
+}
+
+// listTools describes the permitted tools, sorted by name
+func (s *Server) listTools() []Tool {
+	var list []Tool
+	for _, tool := range s.registry.List() {
+		if !s.permit(tool) {
+			continue
+		}
+		entry := Tool{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			InputSchema: inputSchema(tool.Schema()),
+		}
+		if tools.IsReadOnly(tool) {
+			entry.Annotations = &struct {
+				ReadOnlyHint bool `json:"readOnlyHint"`
+			}{ReadOnlyHint: true}
+		}
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func (s *Server) callTool(params json.RawMessage) (*callToolResult, *rpcError) {
+	var req callToolParams
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	tool, ok := s.registry.Get(req.Name)
+	if !ok {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + req.Name}
+	}
+	if !s.permit(tool) {
+		return textResult(fmt.Sprintf("%s is not permitted by the server's permission mode", req.Name), true), nil
+	}
+	if req.Arguments == nil {
+		req.Arguments = map[string]interface{}{}
+	}
+
+	if !tools.IsReadOnly(tool) {
+		s.execMu.Lock()
+		defer s.execMu.Unlock()
+	}
+	result := s.registry.Execute(req.Name, req.Arguments)
+	if !result.Success {
+		return textResult(result.Error, true), nil
+	}
+	return textResult(result.Result, false), nil
+}
+
+func textResult(text string, isError bool) *callToolResult {
+	return &callToolResult{Content: []content{{Type: "text", Text: text}}, IsError: isError}
+}
+
+// inputSchema converts a tool schema to JSON schema
+func inputSchema(schema tools.ToolSchema) json.RawMessage {
+	value := map[string]interface{}{"type": "object", "properties": schema.Properties}
+	if schema.Properties == nil {
+		value["properties"] = map[string]interface{}{}
+	}
+	if len(schema.Required) > 0 {
+		value["required"] = schema.Required
+	}
+	data, _ := json.Marshal(value)
+	return data
+}
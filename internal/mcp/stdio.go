@@ -0,0 +1,214 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxMessageSize bounds one line of a stdio server's output
+const maxMessageSize = 16 * 1024 * 1024
+
+// stderrLines is how much of a stdio server's stderr is kept for errors
+const stderrLines = 20
+
+// stdioTransport talks to a server process over newline-delimited JSON on
+// its stdin and stdout
+type stdioTransport struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	pending map[string]chan *message
+	err     error // set once the process's output ends
+	stderr  *tailWriter
+	done    chan struct{}
+}
+
+func startStdio(cfg ServerConfig) (*stdioTransport, error) {
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Dir = cfg.Dir
+	cmd.Env = os.Environ()
+	for _, entry := range cfg.Env {
+		cmd.Env = append(cmd.Env, os.ExpandEnv(entry))
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	t := &stdioTransport{
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[string]chan *message),
+		stderr:  &tailWriter{},
+		done:    make(chan struct{}),
+	}
+	cmd.Stderr = t.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", cfg.Command, err)
+	}
+	go t.readLoop(stdout)
+	return t, nil
+}
+
+func (t *stdioTransport) request(ctx context.Context, req *message) (*message, error) {
+	reply := make(chan *message, 1)
+	key := string(req.ID)
+	t.mu.Lock()
+	exited := t.err != nil
+	if !exited {
+		t.pending[key] = reply
+	}
+	t.mu.Unlock()
+	if exited {
+		return nil, t.exitError()
+	}
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, key)
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(req); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-reply:
+		return resp, nil
+	case <-t.done:
+		return nil, t.exitError()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) notify(ctx context.Context, msg *message) error {
+	return t.write(msg)
+}
+
+// close ends the server by closing its stdin, killing it if it doesn't exit
+func (t *stdioTransport) close() error {
+	t.stdin.Close()
+	select {
+	case <-t.done:
+	case <-time.After(2 * time.Second):
+		t.cmd.Process.Kill()
+		<-t.done
+	}
+	return nil
+}
+
+func (t *stdioTransport) write(msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.stdin.Write(append(data, '\n')); err != nil {
+		return t.exitError()
+	}
+	return nil
+}
+
+// readLoop hands responses to the waiting requests and answers the server's
+// own requests until the process's output ends
+func (t *stdioTransport) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue // servers sometimes log to stdout
+		}
+		switch {
+		case msg.isResponse():
+			t.mu.Lock()
+			reply := t.pending[string(msg.ID)]
+			t.mu.Unlock()
+			if reply != nil {
+				reply <- &msg
+			}
+		case msg.Method != "" && len(msg.ID) > 0:
+			t.write(answerServerRequest(&msg))
+		}
+	}
+
+	err := scanner.Err()
+	t.cmd.Wait()
+	t.mu.Lock()
+	if err == nil {
+		err = fmt.Errorf("server exited")
+	}
+	t.err = err
+	t.mu.Unlock()
+	close(t.done)
+}
+
+// exitError describes why the server stopped, with the end of its stderr
+func (t *stdioTransport) exitError() error {
+	t.mu.Lock()
+	err := t.err
+	t.mu.Unlock()
+	if err == nil {
+		err = fmt.Errorf("server closed its input")
+	}
+	if tail := t.stderr.String(); tail != "" {
+		return fmt.Errorf("%v: %s", err, tail)
+	}
+	return err
+}
+
+// tailWriter keeps the last lines written to it
+type tailWriter struct {
+	mu    sync.Mutex
+	lines []string
+	part  string
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	lines := strings.Split(w.part+string(p), "\n")
+	w.part = lines[len(lines)-1]
+	w.lines = append(w.lines, lines[:len(lines)-1]...)
+	if len(w.lines) > stderrLines {
+		w.lines = w.lines[len(w.lines)-stderrLines:]
+	}
+	return len(p), nil
+}
+
+func (w *tailWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	lines := w.lines
+	if w.part != "" {
+		lines = append(lines[:len(lines):len(lines)], w.part)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// answerServerRequest replies to a request from the server: pings succeed
+// and everything else is unsupported
+func answerServerRequest(req *message) *message {
+	reply := &message{JSONRPC: "2.0", ID: req.ID}
+	if req.Method == "ping" {
+		reply.Result = json.RawMessage("{}")
+	} else {
+		reply.Error = &rpcError{Code: codeMethodNotFound, Message: "method not supported: " + req.Method}
+	}
+	return reply
+}
@@ -0,0 +1,255 @@
+// Command fakeserver is a small MCP server for trying the MCP client by
+// hand. It speaks stdio by default, or streamable HTTP at /mcp with -http,
+// and offers a few tools:
+//
+//	echo  returns its text (read-only)
+//	add   adds two numbers
+//	fail  returns a tool error
+//	sleep waits for some seconds, to try timeouts
+//	exit  makes the server exit without replying
+//
+// With -page-size, tools/list returns the tools in pages of that size.
+// Configure it as a server with command "go" and args
+// ["run", "./internal/mcp/testdata/fakeserver"], or build it first. The
+// client tests in internal/mcp run it over stdio.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+var tools = []map[string]interface{}{
+	{
+		"name":        "echo",
+		"description": "Returns the text it is given",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"text": map[string]interface{}{"type": "string", "description": "Text to echo"}},
+			"required":   []string{"text"},
+		},
+		"annotations": map[string]interface{}{"readOnlyHint": true},
+	},
+	{
+		"name":        "add",
+		"description": "Adds two numbers",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"a": map[string]interface{}{"type": "number"},
+				"b": map[string]interface{}{"type": []string{"number", "null"}},
+			},
+			"required": []string{"a", "b"},
+		},
+	},
+	{
+		"name":        "fail",
+		"description": "Always fails with the given message",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"message": map[string]interface{}{"type": "string"}},
+		},
+	},
+	{
+		"name":        "sleep",
+		"description": "Waits for the given number of seconds",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"seconds": map[string]interface{}{"type": "number"}},
+		},
+	},
+	{
+		"name":        "exit",
+		"description": "Makes the server exit",
+		"inputSchema": map[string]interface{}{"type": "object"},
+	},
+}
+
+// pageSize is the number of tools per tools/list page, 0 for all of them
+var pageSize int
+
+func main() {
+	addr := flag.String("http", "", "serve streamable HTTP at this address instead of stdio")
+	sse := flag.Bool("sse", false, "with -http, answer requests with event streams instead of JSON")
+	flag.IntVar(&pageSize, "page-size", 0, "list tools in pages of this size")
+	flag.Parse()
+
+	if *addr != "" {
+		serveHTTP(*addr, *sse)
+		return
+	}
+	serveStdio()
+}
+
+func serveStdio() {
+	log.SetOutput(os.Stderr)
+	log.Println("fake MCP server ready on stdio")
+
+	var writeMu sync.Mutex
+	encoder := json.NewEncoder(os.Stdout)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			log.Printf("invalid message: %v", err)
+			continue
+		}
+		// Handle requests concurrently, as real servers may
+		go func() {
+			if reply := handle(&msg); reply != nil {
+				writeMu.Lock()
+				encoder.Encode(reply)
+				writeMu.Unlock()
+			}
+		}()
+	}
+}
+
+func serveHTTP(addr string, sse bool) {
+	const sessionID = "fake-session"
+	http.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case http.MethodPost:
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var msg message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if msg.Method == "initialize" {
+			w.Header().Set("Mcp-Session-Id", sessionID)
+		} else if r.Header.Get("Mcp-Session-Id") != sessionID {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+
+		reply := handle(&msg)
+		if reply == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		data, _ := json.Marshal(reply)
+		if sse {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/message\",\"params\":{\"level\":\"info\",\"data\":\"working\"}}\n\n")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+	log.Printf("fake MCP server ready on http://%s/mcp", addr)
+	log.Fatal(http.ListenAndServe(addr, nil))
+}
+
+// handle answers a request, or returns nil for a notification
+func handle(msg *message) *message {
+	if len(msg.ID) == 0 {
+		return nil
+	}
+	reply := &message{JSONRPC: "2.0", ID: msg.ID}
+	switch msg.Method {
+	case "initialize":
+		reply.Result = map[string]interface{}{
+			"protocolVersion": "2025-06-18",
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]interface{}{"name": "fakeserver", "version": "0.1"},
+		}
+	case "ping":
+		reply.Result = map[string]interface{}{}
+	case "tools/list":
+		reply.Result = listTools(msg.Params)
+	case "tools/call":
+		reply.Result = callTool(msg.Params)
+	default:
+		reply.Error = &rpcError{Code: -32601, Message: "method not found: " + msg.Method}
+	}
+	return reply
+}
+
+// listTools returns the page of tools starting at the cursor, which is the
+// index of its first tool
+func listTools(params json.RawMessage) map[string]interface{} {
+	var list struct {
+		Cursor string `json:"cursor"`
+	}
+	json.Unmarshal(params, &list)
+	if pageSize <= 0 {
+		return map[string]interface{}{"tools": tools}
+	}
+
+	start, _ := strconv.Atoi(list.Cursor)
+	start = min(max(start, 0), len(tools))
+	end := min(start+pageSize, len(tools))
+	result := map[string]interface{}{"tools": tools[start:end]}
+	if end < len(tools) {
+		result["nextCursor"] = strconv.Itoa(end)
+	}
+	return result
+}
+
+func callTool(params json.RawMessage) map[string]interface{} {
+	var call struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	json.Unmarshal(params, &call)
+
+	text := func(s string, isError bool) map[string]interface{} {
+		return map[string]interface{}{
+			"content": []map[string]interface{}{{"type": "text", "text": s}},
+			"isError": isError,
+		}
+	}
+	switch call.Name {
+	case "echo":
+		s, _ := call.Arguments["text"].(string)
+		return text(s, false)
+	case "add":
+		a, _ := call.Arguments["a"].(float64)
+		b, _ := call.Arguments["b"].(float64)
+		return text(fmt.Sprint(a+b), false)
+	case "fail":
+		s, _ := call.Arguments["message"].(string)
+		return text(strings.TrimSpace("failed as requested: "+s), true)
+	case "sleep":
+		seconds, _ := call.Arguments["seconds"].(float64)
+		time.Sleep(time.Duration(seconds * float64(time.Second)))
+		return text(fmt.Sprintf("slept %gs", seconds), false)
+	case "exit":
+		log.Println("exiting as requested")
+		os.Exit(3)
+	}
+	return text("unknown tool "+call.Name, true)
+}
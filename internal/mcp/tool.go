@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"github.com/ttli3/go-coding-agent/internal/tools"
+)
+
+// maxToolNameLength is the longest function name model APIs accept
+const maxToolNameLength = 64
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// ToolName namespaces a server's tool as mcp__<server>__<tool>. Names too
+// long for model APIs are cut short and end in a hash of the full name, so
+// long names that share a prefix stay distinct.
+func ToolName(server, tool string) string {
+	name := "mcp__" + invalidNameChars.ReplaceAllString(server, "_") + "__" + invalidNameChars.ReplaceAllString(tool, "_")
+	if len(name) > maxToolNameLength {
+		sum := sha256.Sum256([]byte(name))
+		suffix := "_" + hex.EncodeToString(sum[:4])
+		name = name[:maxToolNameLength-len(suffix)] + suffix
+	}
+	return name
+}
+
+// RemoteTool is a tool of an MCP server registered with the agent
+type RemoteTool struct {
+	client *Client
+	tool   Tool
+	name   string
+}
+
+// NewTool adapts one of the client's tools to the agent's tool interface
+func NewTool(client *Client, tool Tool) *RemoteTool {
+	return &RemoteTool{client: client, tool: tool, name: ToolName(client.Name(), tool.Name)}
+}
+
+// Remote returns the tool as the server describes it
+func (t *RemoteTool) Remote() Tool {
+	return t.tool
+}
+
+func (t *RemoteTool) Name() string {
+	return t.name
+}
+
+func (t *RemoteTool) Description() string {
+	return fmt.Sprintf("[MCP server %s] %s", t.client.Name(), t.tool.Description)
+}
+
+// ReadOnly reports whether the tool can run without approval. A server's
+// read-only annotation is only a hint, so it counts only for servers the
+// config trusts; otherwise the tool must be listed in read_only_tools.
+func (t *RemoteTool) ReadOnly() bool {
+	for _, name := range t.client.config.ReadOnlyTools {
+		if name == t.tool.Name {
+			return true
+		}
+	}
+	return t.client.config.Trusted && t.tool.Annotations != nil && t.tool.Annotations.ReadOnlyHint
+}
+
+func (t *RemoteTool) Execute(args map[string]interface{}) (string, error) {
+	return t.client.CallTool(t.tool.Name, args)
+}
+
+func (t *RemoteTool) Schema() tools.ToolSchema {
+	return tools.SchemaFromJSON(t.tool.InputSchema)
+}
@@ -0,0 +1,480 @@
+// Package project detects the languages and sub-projects in a repository and
+// infers the commands used to build, test and lint each of them.
+package project
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ttli3/go-coding-agent/internal/walker"
+)
+
+// maxScanFiles bounds how much of a very large repository is scanned
+const maxScanFiles = 20000
+
+// SubProject is a directory with its own build definition
+type SubProject struct {
+	Path  string   // slash-separated, relative to the root ("." for the root)
+	Kinds []string // e.g. "go", "nodejs", "python", "rust", "bazel", "make"
+	Name  string   // module, package or crate name when declared
+
+	Build string
+	Test  string
+	Lint  string
+
+	MakeTargets []string
+	Notes       []string
+}
+
+// Layout describes everything detected under a project root
+type Layout struct {
+	Root       string
+	Languages  []LanguageCount
+	Workspaces []string
+	Projects   []*SubProject
+}
+
+// LanguageCount is how many source files of a language were found
+type LanguageCount struct {
+	Language string
+	Files    int
+}
+
+// languageByExt maps source file extensions to language names
+var languageByExt = map[string]string{
+	".go": "Go", ".py": "Python", ".js": "JavaScript", ".jsx": "JavaScript",
+	".mjs": "JavaScript", ".ts": "TypeScript", ".tsx": "TypeScript", ".rs": "Rust",
+	".java": "Java", ".kt": "Kotlin", ".rb": "Ruby", ".c": "C", ".h": "C",
+	".cc": "C++", ".cpp": "C++", ".hpp": "C++", ".cs": "C#", ".swift": "Swift",
+	".php": "PHP", ".scala": "Scala", ".sh": "Shell", ".proto": "Protobuf",
+}
+
+// markerFiles are the files that make a directory a sub-project
+var markerFiles = map[string]bool{
+	"go.mod": true, "go.work": true, "package.json": true, "pnpm-workspace.yaml": true,
+	"pyproject.toml": true, "setup.py": true, "requirements.txt": true, "Cargo.toml": true,
+	"WORKSPACE": true, "WORKSPACE.bazel": true, "MODULE.bazel": true, "Makefile": true,
+	"GNUmakefile": true, ".golangci.yml": true, ".golangci.yaml": true,
+}
+
+// layoutCache keeps the layouts already detected, by absolute root, so new
+// sessions and working directory changes don't rescan the whole tree
+var layoutCache = struct {
+	sync.Mutex
+	layouts map[string]*Layout
+}{layouts: make(map[string]*Layout)}
+
+// Detect returns the languages and sub-projects under root. The result is
+// cached per root until Invalidate is called and must not be modified.
+func Detect(root string) *Layout {
+	key := root
+	if abs, err := filepath.Abs(root); err == nil {
+		key = abs
+	}
+	layoutCache.Lock()
+	layout, ok := layoutCache.layouts[key]
+	layoutCache.Unlock()
+	if ok {
+		return layout
+	}
+
+	layout = scan(root)
+	layoutCache.Lock()
+	layoutCache.layouts[key] = layout
+	layoutCache.Unlock()
+	return layout
+}
+
+// Invalidate drops the cached layouts, so the next Detect of each root scans
+// again. Call it after changing a file for which IsMarkerFile is true.
+func Invalidate() {
+	layoutCache.Lock()
+	layoutCache.layouts = make(map[string]*Layout)
+	layoutCache.Unlock()
+}
+
+// IsMarkerFile reports whether a file with this base name defines a sub-project
+func IsMarkerFile(name string) bool {
+	return markerFiles[name]
+}
+
+// scan walks root for languages and sub-projects
+func scan(root string) *Layout {
+	layout := &Layout{Root: root}
+	counts := make(map[string]int)
+	markers := make(map[string]map[string]bool) // dir -> marker file names
+
+	scanned := 0
+	walker.Walk(root, walker.Options{}, func(path, rel string, d fs.DirEntry) error {
+		scanned++
+		if scanned > maxScanFiles {
+			return filepath.SkipAll
+		}
+
+		if lang, ok := languageByExt[strings.ToLower(filepath.Ext(rel))]; ok {
+			counts[lang]++
+		}
+		if markerFiles[d.Name()] {
+			dir := filepath.ToSlash(filepath.Dir(rel))
+			if markers[dir] == nil {
+				markers[dir] = make(map[string]bool)
+			}
+			markers[dir][d.Name()] = true
+		}
+		return nil
+	})
+
+	for lang, n := range counts {
+		layout.Languages = append(layout.Languages, LanguageCount{Language: lang, Files: n})
+	}
+	sort.Slice(layout.Languages, func(i, j int) bool {
+		if layout.Languages[i].Files != layout.Languages[j].Files {
+			return layout.Languages[i].Files > layout.Languages[j].Files
+		}
+		return layout.Languages[i].Language < layout.Languages[j].Language
+	})
+
+	dirs := make([]string, 0, len(markers))
+	for dir := range markers {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		if p := layout.detectSubProject(dir, markers[dir]); p != nil {
+			layout.Projects = append(layout.Projects, p)
+		}
+	}
+	return layout
+}
+
+// detectSubProject infers kinds and commands for one directory
+func (l *Layout) detectSubProject(dir string, files map[string]bool) *SubProject {
+	abs := filepath.Join(l.Root, filepath.FromSlash(dir))
+	p := &SubProject{Path: dir}
+
+	if files["go.work"] {
+		uses := goWorkUses(filepath.Join(abs, "go.work"))
+		l.Workspaces = append(l.Workspaces, workspaceLabel(dir, "Go workspace (go.work)", len(uses), "modules"))
+		if !files["go.mod"] {
+			p.Kinds = append(p.Kinds, "go")
+			p.setCommands(goWorkCommands(uses))
+			p.Notes = append(p.Notes, "go.work workspace root")
+		}
+	}
+	if files["go.mod"] {
+		p.Kinds = append(p.Kinds, "go")
+		p.Name = goModuleName(filepath.Join(abs, "go.mod"))
+		lint := "go vet ./..."
+		if files[".golangci.yml"] || files[".golangci.yaml"] {
+			lint = "golangci-lint run"
+		}
+		p.setCommands("go build ./...", "go test ./...", lint)
+	}
+
+	if files["package.json"] || files["pnpm-workspace.yaml"] {
+		l.detectNode(p, abs, files)
+	}
+
+	if files["Cargo.toml"] {
+		l.detectRust(p, abs)
+	}
+
+	if files["pyproject.toml"] || files["setup.py"] || files["requirements.txt"] {
+		detectPython(p, abs)
+	}
+
+	if files["WORKSPACE"] || files["WORKSPACE.bazel"] || files["MODULE.bazel"] {
+		p.Kinds = append(p.Kinds, "bazel")
+		p.setCommands("bazel build //...", "bazel test //...", "")
+		l.Workspaces = append(l.Workspaces, workspaceLabel(dir, "Bazel workspace", 0, ""))
+	}
+
+	makefile := ""
+	if files["GNUmakefile"] {
+		makefile = "GNUmakefile"
+	} else if files["Makefile"] {
+		makefile = "Makefile"
+	}
+	if makefile != "" {
+		p.MakeTargets = makeTargets(filepath.Join(abs, makefile))
+		if len(p.Kinds) == 0 || len(p.MakeTargets) > 0 {
+			p.Kinds = append(p.Kinds, "make")
+		}
+		// Project-defined targets usually wrap the right flags, so prefer them
+		for _, target := range p.MakeTargets {
+			switch target {
+			case "build":
+				p.Build = "make build"
+			case "test":
+				p.Test = "make test"
+			case "lint":
+				p.Lint = "make lint"
+			}
+		}
+	}
+
+	if len(p.Kinds) == 0 {
+		return nil
+	}
+	return p
+}
+
+// detectNode reads package.json scripts and workspaces and picks the package manager
+func (l *Layout) detectNode(p *SubProject, abs string, files map[string]bool) {
+	p.Kinds = append(p.Kinds, "nodejs")
+	pm := l.nodePackageManager(abs)
+
+	var pkg struct {
+		Name       string            `json:"name"`
+		Scripts    map[string]string `json:"scripts"`
+		Workspaces json.RawMessage   `json:"workspaces"`
+	}
+	if data, err := os.ReadFile(filepath.Join(abs, "package.json")); err == nil {
+		json.Unmarshal(data, &pkg)
+	}
+	if p.Name == "" {
+		p.Name = pkg.Name
+	}
+
+	run := func(script string) string {
+		if _, ok := pkg.Scripts[script]; !ok {
+			return ""
+		}
+		if script == "test" {
+			return pm + " test"
+		}
+		return pm + " run " + script
+	}
+	p.setCommands(run("build"), run("test"), run("lint"))
+
+	rel, _ := filepath.Rel(l.Root, abs)
+	dir := filepath.ToSlash(rel)
+	if files["pnpm-workspace.yaml"] {
+		l.Workspaces = append(l.Workspaces, workspaceLabel(dir, "pnpm workspace", 0, ""))
+		p.Notes = append(p.Notes, "pnpm workspace root; run a package's scripts with pnpm --filter <name>")
+	} else if len(pkg.Workspaces) > 0 && string(pkg.Workspaces) != "null" {
+		l.Workspaces = append(l.Workspaces, workspaceLabel(dir, pm+" workspaces (package.json)", 0, ""))
+		p.Notes = append(p.Notes, pm+" workspaces root")
+	}
+}
+
+// nodePackageManager finds the lockfile in dir or its parents up to the root
+func (l *Layout) nodePackageManager(dir string) string {
+	for {
+		for _, lock := range []struct{ file, pm string }{
+			{"pnpm-lock.yaml", "pnpm"}, {"pnpm-workspace.yaml", "pnpm"},
+			{"yarn.lock", "yarn"}, {"bun.lockb", "bun"}, {"package-lock.json", "npm"},
+		} {
+			if _, err := os.Stat(filepath.Join(dir, lock.file)); err == nil {
+				return lock.pm
+			}
+		}
+		if dir == l.Root || filepath.Dir(dir) == dir {
+			return "npm"
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+var (
+	tomlSection = regexp.MustCompile(`^\s*\[([^\]]+)\]`)
+	tomlName    = regexp.MustCompile(`^\s*name\s*=\s*"([^"]+)"`)
+)
+
+// detectRust reads the crate name and whether Cargo.toml declares a workspace
+func (l *Layout) detectRust(p *SubProject, abs string) {
+	p.Kinds = append(p.Kinds, "rust")
+	build, test, lint := "cargo build", "cargo test", "cargo clippy"
+	defer func() { p.setCommands(build, test, lint) }()
+
+	file, err := os.Open(filepath.Join(abs, "Cargo.toml"))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := tomlSection.FindStringSubmatch(line); m != nil {
+			section = strings.TrimSpace(m[1])
+			if section == "workspace" {
+				rel, _ := filepath.Rel(l.Root, abs)
+				l.Workspaces = append(l.Workspaces, workspaceLabel(filepath.ToSlash(rel), "Cargo workspace", 0, ""))
+				p.Notes = append(p.Notes, "Cargo workspace root; test one crate with cargo test -p <crate>")
+				build, test, lint = "cargo build --workspace", "cargo test --workspace", "cargo clippy --workspace"
+			}
+			continue
+		}
+		if section == "package" && p.Name == "" {
+			if m := tomlName.FindStringSubmatch(line); m != nil {
+				p.Name = m[1]
+			}
+		}
+	}
+}
+
+// detectPython infers pytest and linters from the project files
+func detectPython(p *SubProject, abs string) {
+	p.Kinds = append(p.Kinds, "python")
+
+	pyproject := ""
+	if data, err := os.ReadFile(filepath.Join(abs, "pyproject.toml")); err == nil {
+		pyproject = string(data)
+		if m := tomlName.FindStringSubmatch(firstMatchLine(pyproject, tomlName)); m != nil && p.Name == "" {
+			p.Name = m[1]
+		}
+	}
+
+	usesPytest := strings.Contains(pyproject, "pytest")
+	for _, marker := range []string{"pytest.ini", "conftest.py", "tests"} {
+		if _, err := os.Stat(filepath.Join(abs, marker)); err == nil {
+			usesPytest = true
+		}
+	}
+	test := "python -m unittest"
+	if usesPytest {
+		test = "pytest"
+	}
+
+	lint := ""
+	switch {
+	case strings.Contains(pyproject, "ruff"):
+		lint = "ruff check ."
+	case strings.Contains(pyproject, "flake8"):
+		lint = "flake8"
+	}
+
+	build := ""
+	if strings.Contains(pyproject, "[build-system]") {
+		build = "python -m build"
+	}
+	p.setCommands(build, test, lint)
+}
+
+// setCommands fills in commands not already set by an earlier kind, so the
+// first build system detected in a directory wins
+func (p *SubProject) setCommands(build, test, lint string) {
+	if p.Build == "" {
+		p.Build = build
+	}
+	if p.Test == "" {
+		p.Test = test
+	}
+	if p.Lint == "" {
+		p.Lint = lint
+	}
+}
+
+func firstMatchLine(text string, re *regexp.Regexp) string {
+	for _, line := range strings.Split(text, "\n") {
+		if re.MatchString(line) {
+			return line
+		}
+	}
+	return ""
+}
+
+var makeTarget = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_.-]*)\s*:([^=]|$)`)
+
+// makeTargets lists the explicit targets defined in a Makefile
+func makeTargets(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	seen := make(map[string]bool)
+	var targets []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		m := makeTarget.FindStringSubmatch(scanner.Text())
+		if m == nil || seen[m[1]] || strings.Contains(m[1], "%") {
+			continue
+		}
+		seen[m[1]] = true
+		targets = append(targets, m[1])
+	}
+	return targets
+}
+
+// goModuleName reads the module path from go.mod
+func goModuleName(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// goWorkUses lists the module directories in a go.work file
+func goWorkUses(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var uses []string
+	inBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "use ("):
+			inBlock = true
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock && line != "" && !strings.HasPrefix(line, "//"):
+			uses = append(uses, strings.Fields(line)[0])
+		case strings.HasPrefix(line, "use "):
+			uses = append(uses, strings.Fields(line)[1])
+		}
+	}
+	return uses
+}
+
+// goWorkCommands builds commands covering every module of a workspace, since
+// ./... does not cross module boundaries
+func goWorkCommands(uses []string) (build, test, lint string) {
+	if len(uses) == 0 {
+		return "go build ./...", "go test ./...", "go vet ./..."
+	}
+	patterns := make([]string, len(uses))
+	for i, use := range uses {
+		patterns[i] = strings.TrimSuffix(filepath.ToSlash(use), "/") + "/..."
+		if !strings.HasPrefix(patterns[i], ".") {
+			patterns[i] = "./" + patterns[i]
+		}
+	}
+	all := strings.Join(patterns, " ")
+	return "go build " + all, "go test " + all, "go vet " + all
+}
+
+func workspaceLabel(dir, kind string, members int, noun string) string {
+	label := kind
+	if dir != "." {
+		label += " at " + dir
+	}
+	if members > 0 {
+		label += fmt.Sprintf(" with %d %s", members, noun)
+	}
+	return label
+}
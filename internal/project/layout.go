@@ -0,0 +1,179 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// maxSummaryProjects bounds how many sub-projects are listed in the context
+const maxSummaryProjects = 12
+
+// PrimaryType is the project type of the root directory in the session's
+// naming ("go", "nodejs", "python", "rust"), falling back to the most common
+// language, or "unknown"
+func (l *Layout) PrimaryType() string {
+	if root := l.projectAt("."); root != nil {
+		for _, kind := range root.Kinds {
+			switch kind {
+			case "go", "nodejs", "python", "rust":
+				return kind
+			}
+		}
+	}
+
+	if len(l.Languages) > 0 {
+		switch l.Languages[0].Language {
+		case "Go":
+			return "go"
+		case "JavaScript", "TypeScript":
+			return "nodejs"
+		case "Python":
+			return "python"
+		case "Rust":
+			return "rust"
+		}
+		return strings.ToLower(l.Languages[0].Language)
+	}
+	return "unknown"
+}
+
+// HasKind reports whether any sub-project is of the given kind
+func (l *Layout) HasKind(kind string) bool {
+	for _, p := range l.Projects {
+		for _, k := range p.Kinds {
+			if k == kind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ProjectFor returns the innermost sub-project containing path (absolute or
+// relative to the root), or nil
+func (l *Layout) ProjectFor(path string) *SubProject {
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(l.Root, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return nil
+		}
+		path = rel
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+
+	depth := func(p *SubProject) int {
+		if p.Path == "." {
+			return 0
+		}
+		return strings.Count(p.Path, "/") + 1
+	}
+
+	var best *SubProject
+	for _, p := range l.Projects {
+		if p.Path == "." || path == p.Path || strings.HasPrefix(path, p.Path+"/") {
+			if best == nil || depth(p) > depth(best) {
+				best = p
+			}
+		}
+	}
+	return best
+}
+
+func (l *Layout) projectAt(dir string) *SubProject {
+	for _, p := range l.Projects {
+		if p.Path == dir {
+			return p
+		}
+	}
+	return nil
+}
+
+// Summary describes the languages, workspaces and sub-projects for the system
+// prompt. activePath (a file or directory being worked on) selects the
+// sub-project whose commands should be used.
+func (l *Layout) Summary(activePath string) string {
+	if l == nil || (len(l.Projects) == 0 && len(l.Languages) == 0) {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString("PROJECT LAYOUT:\n")
+
+	if len(l.Languages) > 0 {
+		var langs []string
+		for i, lang := range l.Languages {
+			if i == 6 {
+				break
+			}
+			langs = append(langs, fmt.Sprintf("%s (%d files)", lang.Language, lang.Files))
+		}
+		out.WriteString("Languages: " + strings.Join(langs, ", ") + "\n")
+	}
+	for _, ws := range l.Workspaces {
+		out.WriteString("Workspace: " + ws + "\n")
+	}
+
+	if len(l.Projects) > 0 {
+		out.WriteString("Sub-projects (run commands with run_command's working_dir set to the sub-project's directory):\n")
+		for i, p := range l.Projects {
+			if i == maxSummaryProjects {
+				out.WriteString(fmt.Sprintf("  ... and %d more\n", len(l.Projects)-maxSummaryProjects))
+				break
+			}
+			out.WriteString("  " + p.describe() + "\n")
+		}
+	}
+
+	if activePath != "" {
+		if p := l.ProjectFor(activePath); p != nil && p.Test != "" {
+			out.WriteString(fmt.Sprintf("For the files you are working on, use sub-project %s: ", p.Path))
+			dir := ""
+			if p.Path != "." {
+				dir = filepath.Join(l.Root, filepath.FromSlash(p.Path))
+			}
+			out.WriteString(p.commandLine(dir) + "\n")
+		}
+	}
+
+	return out.String()
+}
+
+// describe formats one sub-project on a line
+func (p *SubProject) describe() string {
+	line := fmt.Sprintf("%s [%s]", p.Path, strings.Join(p.Kinds, ", "))
+	if p.Name != "" {
+		line += " " + p.Name
+	}
+	if cmds := p.commandLine(""); cmds != "" {
+		line += " - " + cmds
+	}
+	for _, note := range p.Notes {
+		line += "; " + note
+	}
+	if len(p.MakeTargets) > 0 {
+		targets := p.MakeTargets
+		if len(targets) > 10 {
+			targets = targets[:10]
+		}
+		line += "; make targets: " + strings.Join(targets, " ")
+	}
+	return line
+}
+
+// commandLine lists the inferred commands, followed by the working_dir to run
+// them in when dir is set. run_command doesn't use a shell, so "cd dir && cmd"
+// wouldn't work.
+func (p *SubProject) commandLine(dir string) string {
+	var parts []string
+	for _, c := range []struct{ label, cmd string }{{"build", p.Build}, {"test", p.Test}, {"lint", p.Lint}} {
+		if c.cmd != "" {
+			parts = append(parts, fmt.Sprintf("%s: `%s`", c.label, c.cmd))
+		}
+	}
+	line := strings.Join(parts, ", ")
+	if dir != "" && line != "" {
+		line += fmt.Sprintf(" (run_command with working_dir=%s)", dir)
+	}
+	return line
+}
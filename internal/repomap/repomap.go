@@ -0,0 +1,254 @@
+// Package repomap builds a compact, token-budgeted map of a repository: its
+// directory layout plus the top-level symbols defined in each source file.
+package repomap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ttli3/go-coding-agent/internal/walker"
+)
+
+const (
+	// maxFiles bounds how many files are indexed in very large repositories
+	maxFiles = 5000
+	// maxSymbolFileSize skips symbol extraction for generated or minified files
+	maxSymbolFileSize = 256 * 1024
+	// maxSymbolsPerFile keeps any one file from dominating the map
+	maxSymbolsPerFile = 12
+	// cacheVersion invalidates caches written by older extraction logic
+	cacheVersion = 1
+)
+
+// FileEntry describes one file in the map
+type FileEntry struct {
+	Path    string   `json:"path"` // slash-separated, relative to the root
+	ModTime int64    `json:"mod_time"`
+	Size    int64    `json:"size"`
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+// Map is the set of indexed files under a root directory
+type Map struct {
+	Root  string
+	Files []*FileEntry
+}
+
+type cacheFile struct {
+	Version int                   `json:"version"`
+	Files   map[string]*FileEntry `json:"files"`
+}
+
+// Build walks root and extracts symbols for each source file, reusing entries
+// from the cache at cachePath whose modification time and size are unchanged.
+// The refreshed cache is written back when cachePath is not empty.
+func Build(root, cachePath string) (*Map, error) {
+	cached := loadCache(cachePath)
+
+	m := &Map{Root: root}
+	err := walker.Walk(root, walker.Options{}, func(path, rel string, d fs.DirEntry) error {
+		if len(m.Files) >= maxFiles {
+			return filepath.SkipAll
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		if entry, ok := cached.Files[rel]; ok && entry.ModTime == info.ModTime().UnixNano() && entry.Size == info.Size() {
+			m.Files = append(m.Files, entry)
+			return nil
+		}
+
+		entry := &FileEntry{Path: rel, ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+		if info.Size() <= maxSymbolFileSize {
+			entry.Symbols = ExtractSymbols(path)
+		}
+		m.Files = append(m.Files, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cachePath != "" {
+		m.saveCache(cachePath)
+	}
+	return m, nil
+}
+
+func loadCache(cachePath string) cacheFile {
+	empty := cacheFile{Files: map[string]*FileEntry{}}
+	if cachePath == "" {
+		return empty
+	}
+
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return empty
+	}
+
+	var cached cacheFile
+	if err := json.Unmarshal(data, &cached); err != nil || cached.Version != cacheVersion || cached.Files == nil {
+		return empty
+	}
+	return cached
+}
+
+func (m *Map) saveCache(cachePath string) {
+	cached := cacheFile{Version: cacheVersion, Files: make(map[string]*FileEntry, len(m.Files))}
+	for _, entry := range m.Files {
+		cached.Files[entry.Path] = entry
+	}
+
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	// The cache is an optimization, so failing to write it is not an error
+	os.WriteFile(cachePath, data, 0644)
+}
+
+// Render formats the map within roughly budgetTokens tokens (4 chars ≈ 1 token).
+// Files are ranked by relevance to the focused files and the task, and the
+// highest ranked files keep their symbols when the budget runs short.
+func (m *Map) Render(budgetTokens int, focusedFiles []string, task string) string {
+	if m == nil || len(m.Files) == 0 || budgetTokens <= 0 {
+		return ""
+	}
+	budget := budgetTokens * 4
+
+	ranked := m.rank(focusedFiles, task)
+
+	header := fmt.Sprintf("REPOSITORY MAP (%d files, most relevant first get symbols):\n", len(m.Files))
+	used := len(header)
+
+	// Pick which files fit, spending the budget on the highest ranked first
+	type selection struct {
+		entry   *FileEntry
+		symbols []string
+	}
+	var selected []selection
+	omitted := 0
+	for _, entry := range ranked {
+		line := len(entry.Path) + 4
+		if used+line > budget {
+			omitted++
+			continue
+		}
+		used += line
+
+		var symbols []string
+		for _, symbol := range entry.Symbols {
+			if used+len(symbol)+6 > budget {
+				break
+			}
+			used += len(symbol) + 6
+			symbols = append(symbols, symbol)
+		}
+		selected = append(selected, selection{entry: entry, symbols: symbols})
+	}
+
+	// Present the selection as a tree in path order
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].entry.Path < selected[j].entry.Path
+	})
+
+	var out strings.Builder
+	out.WriteString(header)
+	currentDir := ""
+	for _, sel := range selected {
+		dir := filepath.ToSlash(filepath.Dir(sel.entry.Path))
+		if dir != currentDir {
+			currentDir = dir
+			if dir != "." {
+				out.WriteString(dir + "/\n")
+			}
+		}
+
+		indent := "  "
+		if dir == "." {
+			indent = ""
+		}
+		out.WriteString(fmt.Sprintf("%s%s\n", indent, filepath.Base(sel.entry.Path)))
+		for _, symbol := range sel.symbols {
+			out.WriteString(fmt.Sprintf("%s    %s\n", indent, symbol))
+		}
+	}
+	if omitted > 0 {
+		out.WriteString(fmt.Sprintf("... %d more files not shown (use list_directory or find_files)\n", omitted))
+	}
+
+	return out.String()
+}
+
+// rank orders files by relevance: focused files first, then files near them or
+// matching words from the task, then shallow files over deeply nested ones
+func (m *Map) rank(focusedFiles []string, task string) []*FileEntry {
+	focused := make(map[string]bool)
+	focusedDirs := make(map[string]bool)
+	for _, file := range focusedFiles {
+		rel, err := filepath.Rel(m.Root, file)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		focused[rel] = true
+		focusedDirs[filepath.ToSlash(filepath.Dir(rel))] = true
+	}
+
+	var keywords []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(task), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_')
+	}) {
+		if len(word) >= 3 {
+			keywords = append(keywords, word)
+		}
+	}
+
+	scores := make(map[*FileEntry]float64, len(m.Files))
+	for _, entry := range m.Files {
+		score := 0.0
+		if focused[entry.Path] {
+			score += 100
+		}
+		if focusedDirs[filepath.ToSlash(filepath.Dir(entry.Path))] {
+			score += 10
+		}
+
+		path := strings.ToLower(entry.Path)
+		for _, keyword := range keywords {
+			if strings.Contains(path, keyword) {
+				score += 5
+			}
+			for _, symbol := range entry.Symbols {
+				if strings.Contains(strings.ToLower(symbol), keyword) {
+					score += 2
+					break
+				}
+			}
+		}
+
+		if len(entry.Symbols) > 0 {
+			score += 1
+		}
+		score -= float64(strings.Count(entry.Path, "/")) * 0.5
+		scores[entry] = score
+	}
+
+	ranked := make([]*FileEntry, len(m.Files))
+	copy(ranked, m.Files)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+		return ranked[i].Path < ranked[j].Path
+	})
+	return ranked
+}
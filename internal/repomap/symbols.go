@@ -0,0 +1,174 @@
+package repomap
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// symbolPatterns recognizes top-level declarations in languages without a
+// parser in the standard library. The first capture group is the symbol.
+var symbolPatterns = map[string][]*regexp.Regexp{
+	".py": {
+		regexp.MustCompile(`^(class \w+)`),
+		regexp.MustCompile(`^(?:async )?(def \w+)`),
+	},
+	".js":  jsPatterns,
+	".jsx": jsPatterns,
+	".ts":  jsPatterns,
+	".tsx": jsPatterns,
+	".mjs": jsPatterns,
+	".rs": {
+		regexp.MustCompile(`^(?:pub(?:\([^)]*\))? )?((?:async )?fn \w+)`),
+		regexp.MustCompile(`^(?:pub(?:\([^)]*\))? )?((?:struct|enum|trait|type|mod) \w+)`),
+		regexp.MustCompile(`^(impl(?:<[^>]*>)? [\w:<>, ]+?)\s*\{`),
+	},
+	".java": {
+		regexp.MustCompile(`^(?:public |protected |private |abstract |final |static )*((?:class|interface|enum|record) \w+)`),
+	},
+	".rb": {
+		regexp.MustCompile(`^((?:class|module) [\w:]+)`),
+		regexp.MustCompile(`^\s{0,2}(def [\w.?!]+)`),
+	},
+	".c":   cPatterns,
+	".h":   cPatterns,
+	".cpp": cPatterns,
+	".hpp": cPatterns,
+	".cc":  cPatterns,
+}
+
+var jsPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(?:export )?(?:default )?(?:abstract )?((?:class|interface|enum) \w+)`),
+	regexp.MustCompile(`^(?:export )?(?:default )?(?:async )?(function\*? ?\w+)`),
+	regexp.MustCompile(`^(?:export )?(type \w+)\s*=`),
+	regexp.MustCompile(`^export (?:const|let|var) (\w+)`),
+}
+
+var cPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^((?:struct|class|enum|union) \w+)\s*\{`),
+	regexp.MustCompile(`^(?:static |inline |extern )*[\w:<>*& ]+?[ *&](\w+)\([^;]*$`),
+}
+
+// ExtractSymbols returns the top-level symbols declared in a source file, or
+// nil for files in languages it does not understand
+func ExtractSymbols(path string) []string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".go" {
+		return goSymbols(path)
+	}
+
+	patterns, ok := symbolPatterns[ext]
+	if !ok {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var symbols []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxSymbolFileSize)
+	for scanner.Scan() && len(symbols) < maxSymbolsPerFile {
+		line := scanner.Text()
+		for _, pattern := range patterns {
+			if match := pattern.FindStringSubmatch(line); match != nil {
+				symbols = append(symbols, strings.TrimSpace(match[1]))
+				break
+			}
+		}
+	}
+	return symbols
+}
+
+// goSymbols lists exported top-level Go declarations, falling back to
+// unexported ones for files (such as main packages) that export nothing
+func goSymbols(path string) []string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	var exported, unexported []string
+	add := func(name, symbol string) {
+		if ast.IsExported(name) {
+			exported = append(exported, symbol)
+		} else if name != "_" && name != "init" {
+			unexported = append(unexported, symbol)
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				receiver := receiverName(d.Recv.List[0].Type)
+				if !ast.IsExported(receiver) {
+					continue
+				}
+				add(d.Name.Name, fmt.Sprintf("func (%s) %s", receiver, d.Name.Name))
+			} else {
+				add(d.Name.Name, "func "+d.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Name.Name, fmt.Sprintf("type %s %s", s.Name.Name, typeKind(s.Type)))
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						add(name.Name, fmt.Sprintf("%s %s", d.Tok, name.Name))
+					}
+				}
+			}
+		}
+	}
+
+	symbols := exported
+	if len(symbols) == 0 {
+		symbols = unexported
+	}
+	if len(symbols) > maxSymbolsPerFile {
+		symbols = symbols[:maxSymbolsPerFile]
+	}
+	return symbols
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+func typeKind(expr ast.Expr) string {
+	switch expr.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	case *ast.FuncType:
+		return "func"
+	case *ast.MapType:
+		return "map"
+	case *ast.ArrayType:
+		return "slice"
+	}
+	return ""
+}
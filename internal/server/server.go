@@ -0,0 +1,299 @@
+// Package server exposes agent sessions over a local HTTP API: messages are
+// posted, progress is streamed as server-sent events and tool calls outside
+// a session's permission mode wait for approval.
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ttli3/go-coding-agent/internal/agent"
+	"github.com/ttli3/go-coding-agent/internal/config"
+)
+
+// Options configures the server
+type Options struct {
+	Token          string // required on every request
+	PermissionMode string // default for sessions that don't choose one
+}
+
+// Server holds the sessions and serves the API
+type Server struct {
+	cfg      *config.Config
+	opts     Options
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// New creates a server; sessions are created on request
+func New(cfg *config.Config, opts Options) *Server {
+	if opts.PermissionMode == "" {
+		opts.PermissionMode = agent.PermissionEdit
+	}
+	return &Server{cfg: cfg, opts: opts, sessions: make(map[string]*session)}
+}
+
+// Handler returns the API, requiring the token on every request
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /sessions", s.createSession)
+	mux.HandleFunc("GET /sessions", s.listSessions)
+	mux.HandleFunc("GET /sessions/{id}", s.withSession(s.getSession))
+	mux.HandleFunc("DELETE /sessions/{id}", s.withSession(s.deleteSession))
+	mux.HandleFunc("POST /sessions/{id}/messages", s.withSession(s.postMessage))
+	mux.HandleFunc("GET /sessions/{id}/events", s.withSession(s.streamEvents))
+	mux.HandleFunc("GET /sessions/{id}/approvals", s.withSession(s.listApprovals))
+	mux.HandleFunc("POST /sessions/{id}/approvals/{call}", s.withSession(s.answerApproval))
+	mux.HandleFunc("GET /sessions/{id}/files", s.withSession(s.listFiles))
+	return s.authenticate(mux)
+}
+
+// GenerateToken returns a random token for a server started without one
+func GenerateToken() (string, error) {
+	return randomHex(24)
+}
+
+// CheckLoopback refuses a TCP address that isn't on this machine
+func CheckLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("address %q is not local: bind to 127.0.0.1, ::1 or localhost, or use a Unix socket", addr)
+}
+
+// authenticate accepts the token as a bearer token, or as the token query
+// parameter for clients such as EventSource that can't set headers
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withSession looks up the session named in the path
+func (s *Server) withSession(handler func(http.ResponseWriter, *http.Request, *session)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		sess := s.sessions[r.PathValue("id")]
+		s.mu.Unlock()
+		if sess == nil {
+			writeError(w, http.StatusNotFound, "no such session")
+			return
+		}
+		handler(w, r, sess)
+	}
+}
+
+// createRequest is the optional body of POST /sessions
+type createRequest struct {
+	PermissionMode  string   `json:"permission_mode"`
+	AllowedTools    []string `json:"allowed_tools"`
+	DisallowedTools []string `json:"disallowed_tools"`
+	Model           string   `json:"model"`
+}
+
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+	}
+	if req.PermissionMode == "" {
+		req.PermissionMode = s.opts.PermissionMode
+	}
+	policy := &agent.ToolPolicy{Mode: req.PermissionMode, Allowed: req.AllowedTools, Disallowed: req.DisallowedTools}
+	if err := policy.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	cfg := *s.cfg
+	if req.Model != "" {
+		cfg.OpenRouter.Model = req.Model
+	}
+	sess := newSession(id, &cfg, policy)
+
+	s.mu.Lock()
+	s.sessions[id] = sess
+	s.mu.Unlock()
+	writeJSON(w, http.StatusCreated, sess.info())
+}
+
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	infos := make([]sessionInfo, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		infos = append(infos, sess.info())
+	}
+	s.mu.Unlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Created.Before(infos[j].Created) })
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *Server) getSession(w http.ResponseWriter, r *http.Request, sess *session) {
+	writeJSON(w, http.StatusOK, sess.info())
+}
+
+// deleteSession forgets the session and refuses its pending tool calls; a
+// message being processed still runs to the end
+func (s *Server) deleteSession(w http.ResponseWriter, r *http.Request, sess *session) {
+	s.mu.Lock()
+	delete(s.sessions, sess.id)
+	s.mu.Unlock()
+	sess.close()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// messageRequest is the body of POST /sessions/{id}/messages
+type messageRequest struct {
+	Content string `json:"content"`
+}
+
+// postMessage starts processing a message and returns at once; progress and
+// the result arrive on the event stream after the returned event id
+func (s *Server) postMessage(w http.ResponseWriter, r *http.Request, sess *session) {
+	var req messageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		writeError(w, http.StatusBadRequest, "content is empty")
+		return
+	}
+	after, err := sess.start(req.Content)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"status": "accepted", "after": after})
+}
+
+// keepAliveInterval is how often an idle event stream gets a comment, so
+// proxies and clients don't time it out
+const keepAliveInterval = 15 * time.Second
+
+// streamEvents sends the session's events as server-sent events, starting
+// after the Last-Event-ID header or the after query parameter
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request, sess *session) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	cursor := 0
+	for _, value := range []string{r.Header.Get("Last-Event-ID"), r.URL.Query().Get("after")} {
+		if value != "" {
+			if _, err := fmt.Sscanf(value, "%d", &cursor); err != nil || cursor < 0 {
+				writeError(w, http.StatusBadRequest, "invalid event id "+value)
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		events, wait, closed := sess.eventsAfter(cursor)
+		for _, event := range events {
+			data, _ := json.Marshal(event.event)
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.id, event.event.Type, data)
+			cursor = event.id
+		}
+		if len(events) > 0 {
+			flusher.Flush()
+		}
+		if closed {
+			return
+		}
+
+		select {
+		case <-wait:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) listApprovals(w http.ResponseWriter, r *http.Request, sess *session) {
+	writeJSON(w, http.StatusOK, sess.pendingApprovals())
+}
+
+// approvalRequest is the body of POST /sessions/{id}/approvals/{call}
+type approvalRequest struct {
+	Approve bool   `json:"approve"`
+	Reason  string `json:"reason"`
+}
+
+func (s *Server) answerApproval(w http.ResponseWriter, r *http.Request, sess *session) {
+	var req approvalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if !sess.answer(r.PathValue("call"), req.Approve, req.Reason) {
+		writeError(w, http.StatusNotFound, "no pending approval for that tool call")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listFiles(w http.ResponseWriter, r *http.Request, sess *session) {
+	writeJSON(w, http.StatusOK, map[string][]string{"files": sess.changedFiles()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,407 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/ttli3/go-coding-agent/internal/agent"
+	"github.com/ttli3/go-coding-agent/internal/config"
+	"github.com/ttli3/go-coding-agent/internal/openrouter"
+	"github.com/ttli3/go-coding-agent/internal/ui"
+)
+
+const testToken = "secret"
+
+// workDir is the git repository the tests' sessions work in
+var workDir string
+
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "server-home")
+	if err != nil {
+		panic(err)
+	}
+	workDir = filepath.Join(home, "project")
+	os.Mkdir(workDir, 0755)
+	if out, err := exec.Command("git", "init", "-q", workDir).CombinedOutput(); err != nil {
+		panic("git init: " + err.Error() + "\n" + string(out))
+	}
+	os.Setenv("HOME", home)
+	os.Chdir(workDir)
+	color.Output = io.Discard
+	ui.SetNonInteractive(true)
+
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
+// fakeModel answers chat requests from a script keyed by the user's
+// message: "write <file>" writes the file, "run <file>" touches it with
+// run_command, and anything else gets a plain reply. Once tool results
+// arrive it replies "done".
+func fakeModel(t *testing.T) string {
+	var calls atomic.Int64
+	model := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openrouter.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		last := req.Messages[len(req.Messages)-1]
+
+		reply := openrouter.Message{Role: "assistant", Content: "hi"}
+		fields := strings.Fields(last.Content)
+		switch {
+		case strings.HasPrefix(last.Content, "Tool execution results"):
+			reply.Content = "done"
+		case len(fields) == 2 && fields[0] == "write":
+			reply = toolCallMessage(calls.Add(1), "write_file", map[string]string{"path": fields[1], "content": "hello\n"})
+		case len(fields) == 2 && fields[0] == "run":
+			reply = toolCallMessage(calls.Add(1), "run_command", map[string]string{"command": "touch " + fields[1]})
+		}
+		json.NewEncoder(w).Encode(openrouter.ChatResponse{
+			Choices: []openrouter.Choice{{Message: reply}},
+			Usage:   openrouter.Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12},
+		})
+	}))
+	t.Cleanup(model.Close)
+	return model.URL
+}
+
+func toolCallMessage(id int64, tool string, args map[string]string) openrouter.Message {
+	data, _ := json.Marshal(args)
+	return openrouter.Message{Role: "assistant", ToolCalls: []openrouter.ToolCall{{
+		ID:       fmt.Sprintf("call_%d", id),
+		Type:     "function",
+		Function: openrouter.ToolCallFunction{Name: tool, Arguments: string(data)},
+	}}}
+}
+
+// newTestServer serves the API with sessions that talk to the fake model
+func newTestServer(t *testing.T) *httptest.Server {
+	cfg := &config.Config{}
+	cfg.OpenRouter = config.OpenRouterConfig{APIKey: "key", BaseURL: fakeModel(t), Model: "test-model"}
+	cfg.Agent.MaxTokens = 100
+	srv := httptest.NewServer(New(cfg, Options{Token: testToken}).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// do sends an authenticated request and decodes a JSON reply into out
+func do(t *testing.T, srv *httptest.Server, method, path, body string, out interface{}) int {
+	t.Helper()
+	req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.StatusCode
+}
+
+func createSession(t *testing.T, srv *httptest.Server, body string) string {
+	t.Helper()
+	var info sessionInfo
+	if status := do(t, srv, "POST", "/sessions", body, &info); status != http.StatusCreated {
+		t.Fatalf("create session: status %d", status)
+	}
+	t.Cleanup(func() { do(t, srv, "DELETE", "/sessions/"+info.ID, "", nil) })
+	return info.ID
+}
+
+func postMessage(t *testing.T, srv *httptest.Server, id, content string) {
+	t.Helper()
+	body, _ := json.Marshal(messageRequest{Content: content})
+	if status := do(t, srv, "POST", "/sessions/"+id+"/messages", string(body), nil); status != http.StatusAccepted {
+		t.Fatalf("post message: status %d", status)
+	}
+}
+
+// sseEvent is one event read from a stream
+type sseEvent struct {
+	id    int
+	event streamEvent
+}
+
+// readEvents streams a session's events with the given Last-Event-ID (or
+// none) until one has the type until, or the stream ends
+func readEvents(t *testing.T, srv *httptest.Server, id, lastEventID, until string) []sseEvent {
+	t.Helper()
+	resp := openEvents(t, srv, id, lastEventID)
+	defer resp.Body.Close()
+	events, err := collectEvents(resp, until)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+// openEvents starts streaming a session's events, returning once the
+// stream is established
+func openEvents(t *testing.T, srv *httptest.Server, id, lastEventID string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest("GET", srv.URL+"/sessions/"+id+"/events?token="+testToken, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("stream events: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		t.Fatalf("stream events: status %d", resp.StatusCode)
+	}
+	return resp
+}
+
+// collectEvents reads events until one has the type until or the stream
+// ends, giving up after a timeout
+func collectEvents(resp *http.Response, until string) ([]sseEvent, error) {
+	timer := time.AfterFunc(10*time.Second, func() { resp.Body.Close() })
+	defer timer.Stop()
+
+	var events []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			current.id, _ = strconv.Atoi(strings.TrimPrefix(line, "id: "))
+		case strings.HasPrefix(line, "data: "):
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &current.event)
+		case line == "" && current.id != 0:
+			events = append(events, current)
+			if current.event.Type == until {
+				return events, nil
+			}
+			current = sseEvent{}
+		}
+	}
+	if until != "" || scanner.Err() != nil {
+		return events, fmt.Errorf("stream ended without a %s event; got %v", until, eventTypes(events))
+	}
+	return events, nil
+}
+
+func eventTypes(events []sseEvent) []string {
+	var types []string
+	for _, e := range events {
+		types = append(types, e.event.Type)
+	}
+	return types
+}
+
+func findEvent(events []sseEvent, eventType string) *streamEvent {
+	for i := range events {
+		if events[i].event.Type == eventType {
+			return &events[i].event
+		}
+	}
+	return nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	srv := newTestServer(t)
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"no token", "/sessions", "", http.StatusUnauthorized},
+		{"wrong token", "/sessions", "Bearer nope", http.StatusUnauthorized},
+		{"bearer token", "/sessions", "Bearer " + testToken, http.StatusOK},
+		{"query token", "/sessions?token=" + testToken, "", http.StatusOK},
+		{"wrong query token", "/sessions?token=nope", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", srv.URL+tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestEventStreamResumes(t *testing.T) {
+	srv := newTestServer(t)
+	id := createSession(t, srv, "")
+	postMessage(t, srv, id, "hello")
+
+	events := readEvents(t, srv, id, "", eventResult)
+	if got := strings.Join(eventTypes(events), " "); got != "message message result" {
+		t.Fatalf("events = %q", got)
+	}
+	for i, e := range events {
+		if e.id != i+1 {
+			t.Errorf("event %d has id %d", i, e.id)
+		}
+	}
+	if result := events[2].event; result.Content != "hi" || result.Usage == nil || result.Usage.TotalTokens != 12 {
+		t.Errorf("result = %+v", result)
+	}
+
+	// Reconnecting with Last-Event-ID picks up after that event
+	resumed := readEvents(t, srv, id, "2", eventResult)
+	if len(resumed) != 1 || resumed[0].id != 3 {
+		t.Errorf("resumed events = %+v", resumed)
+	}
+
+	if status := do(t, srv, "GET", "/sessions/"+id+"/events?after=x", "", nil); status != http.StatusBadRequest {
+		t.Errorf("invalid cursor: status %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestSessionsAreNotSaved(t *testing.T) {
+	srv := newTestServer(t)
+	id := createSession(t, srv, "")
+	postMessage(t, srv, id, "hello")
+	readEvents(t, srv, id, "", eventResult)
+
+	home, _ := os.UserHomeDir()
+	if _, err := os.Stat(filepath.Join(home, ".agent_go_session.json")); err == nil {
+		t.Error("a server session was saved to the terminal session file")
+	}
+}
+
+func TestApproveToolCall(t *testing.T) {
+	srv := newTestServer(t)
+	id := createSession(t, srv, `{"permission_mode": "read-only"}`)
+	postMessage(t, srv, id, "write approved.txt")
+
+	events := readEvents(t, srv, id, "", agent.EventApproval)
+	request := events[len(events)-1].event
+	if request.Tool != "write_file" {
+		t.Fatalf("approval request = %+v", request)
+	}
+	var pending []agent.Event
+	do(t, srv, "GET", "/sessions/"+id+"/approvals", "", &pending)
+	if len(pending) != 1 || pending[0].ID != request.ID {
+		t.Fatalf("pending approvals = %+v", pending)
+	}
+
+	if status := do(t, srv, "POST", "/sessions/"+id+"/approvals/"+request.ID, `{"approve": true}`, nil); status != http.StatusNoContent {
+		t.Fatalf("approve: status %d", status)
+	}
+	if status := do(t, srv, "POST", "/sessions/"+id+"/approvals/"+request.ID, `{"approve": true}`, nil); status != http.StatusNotFound {
+		t.Errorf("second answer: status %d, want %d", status, http.StatusNotFound)
+	}
+
+	events = readEvents(t, srv, id, "", eventResult)
+	if resolved := findEvent(events, eventApprovalResolved); resolved == nil || resolved.Approved == nil || !*resolved.Approved {
+		t.Errorf("approval_resolved = %+v", resolved)
+	}
+	if changed := findEvent(events, agent.EventFileChanged); changed == nil || changed.Path != "approved.txt" {
+		t.Errorf("file_changed = %+v", changed)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "approved.txt")); err != nil {
+		t.Errorf("approved write didn't happen: %v", err)
+	}
+	var files map[string][]string
+	do(t, srv, "GET", "/sessions/"+id+"/files", "", &files)
+	if got := strings.Join(files["files"], " "); got != "approved.txt" {
+		t.Errorf("files = %q", got)
+	}
+}
+
+func TestDenyToolCall(t *testing.T) {
+	srv := newTestServer(t)
+	id := createSession(t, srv, `{"permission_mode": "read-only"}`)
+	postMessage(t, srv, id, "write denied.txt")
+
+	events := readEvents(t, srv, id, "", agent.EventApproval)
+	request := events[len(events)-1].event
+	do(t, srv, "POST", "/sessions/"+id+"/approvals/"+request.ID, `{"approve": false, "reason": "not now"}`, nil)
+
+	events = readEvents(t, srv, id, "", eventResult)
+	resolved := findEvent(events, eventApprovalResolved)
+	if resolved == nil || resolved.Approved == nil || *resolved.Approved || resolved.Reason != "not now" {
+		t.Errorf("approval_resolved = %+v", resolved)
+	}
+	if result := findEvent(events, agent.EventToolResult); result == nil || !result.IsError {
+		t.Errorf("tool_result = %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "denied.txt")); err == nil {
+		t.Error("denied write happened")
+	}
+}
+
+func TestApprovalTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { approvalTimeout = timeout }(approvalTimeout)
+	approvalTimeout = 50 * time.Millisecond
+
+	srv := newTestServer(t)
+	id := createSession(t, srv, `{"permission_mode": "read-only"}`)
+	postMessage(t, srv, id, "write late.txt")
+
+	events := readEvents(t, srv, id, "", eventResult)
+	resolved := findEvent(events, eventApprovalResolved)
+	if resolved == nil || resolved.Approved == nil || *resolved.Approved || !strings.Contains(resolved.Reason, "no answer") {
+		t.Errorf("approval_resolved = %+v", resolved)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "late.txt")); err == nil {
+		t.Error("unanswered write happened")
+	}
+}
+
+func TestCloseDeniesPendingApprovals(t *testing.T) {
+	srv := newTestServer(t)
+	id := createSession(t, srv, `{"permission_mode": "read-only"}`)
+	postMessage(t, srv, id, "write closed.txt")
+	readEvents(t, srv, id, "", agent.EventApproval)
+
+	// The stream of a closed session ends after its last events
+	resp := openEvents(t, srv, id, "")
+	defer resp.Body.Close()
+	if status := do(t, srv, "DELETE", "/sessions/"+id, "", nil); status != http.StatusNoContent {
+		t.Fatalf("delete: status %d", status)
+	}
+	events, err := collectEvents(resp, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved := findEvent(events, eventApprovalResolved)
+	if resolved == nil || resolved.Approved == nil || *resolved.Approved || resolved.Reason != "the session was closed" {
+		t.Errorf("approval_resolved = %+v", resolved)
+	}
+	if status := do(t, srv, "GET", "/sessions/"+id, "", nil); status != http.StatusNotFound {
+		t.Errorf("closed session: status %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestFilesChangedByCommands(t *testing.T) {
+	srv := newTestServer(t)
+	id := createSession(t, srv, `{"permission_mode": "all"}`)
+	postMessage(t, srv, id, "run touched.txt")
+	readEvents(t, srv, id, "", eventResult)
+
+	var files map[string][]string
+	do(t, srv, "GET", "/sessions/"+id+"/files", "", &files)
+	if got := strings.Join(files["files"], " "); got != "touched.txt" {
+		t.Errorf("files = %q", got)
+	}
+}
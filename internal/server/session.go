@@ -0,0 +1,285 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ttli3/go-coding-agent/internal/agent"
+	"github.com/ttli3/go-coding-agent/internal/config"
+)
+
+// Event types added by the server to the agent's
+const (
+	eventResult           = "result"            // a message finished processing
+	eventApprovalResolved = "approval_resolved" // a pending tool call was approved or denied
+)
+
+// approvalTimeout is how long a tool call waits for an answer before it is
+// denied; a variable so tests can shorten it
+var approvalTimeout = 10 * time.Minute
+
+// streamEvent is an agent event with the fields of the server's own events
+type streamEvent struct {
+	agent.Event
+	Approved           *bool    `json:"approved,omitempty"`
+	Reason             string   `json:"reason,omitempty"`
+	Error              string   `json:"error,omitempty"`
+	VerificationFailed bool     `json:"verification_failed,omitempty"`
+	Files              []string `json:"files,omitempty"`
+}
+
+// numberedEvent is an event with its id in the session's stream
+type numberedEvent struct {
+	id    int
+	event streamEvent
+}
+
+// decision answers an approval request
+type decision struct {
+	approved bool
+	reason   string
+}
+
+// approval is a tool call waiting for an answer
+type approval struct {
+	eventID int
+	request agent.Event
+	answer  chan decision // buffered, so answering never blocks
+}
+
+// session is one agent conversation. Its fields are guarded by mu; the
+// agent itself is only used by the goroutine processing a message.
+type session struct {
+	id      string
+	created time.Time
+	model   string
+	policy  *agent.ToolPolicy
+	agent   *agent.Agent
+
+	mu        sync.Mutex
+	busy      bool
+	closed    bool
+	events    []streamEvent // event ids start at 1
+	wait      chan struct{} // closed and replaced when an event is added
+	approvals map[string]*approval
+	files     []string // changed files, relative to the project root when inside it
+}
+
+// sessionInfo describes a session
+type sessionInfo struct {
+	ID               string    `json:"id"`
+	Created          time.Time `json:"created"`
+	Model            string    `json:"model"`
+	PermissionMode   string    `json:"permission_mode"`
+	Busy             bool      `json:"busy"`
+	LastEventID      int       `json:"last_event_id"`
+	PendingApprovals int       `json:"pending_approvals"`
+	Files            []string  `json:"files"`
+}
+
+func newSession(id string, cfg *config.Config, policy *agent.ToolPolicy) *session {
+	sess := &session{
+		id:        id,
+		created:   time.Now(),
+		model:     cfg.OpenRouter.Model,
+		policy:    policy,
+		wait:      make(chan struct{}),
+		approvals: make(map[string]*approval),
+	}
+	sess.agent = agent.NewEphemeralAgent(cfg)
+	sess.agent.SetToolPolicy(policy)
+	sess.agent.SetApprover(sess.approve)
+	sess.agent.SetEventHandler(sess.record)
+	return sess
+}
+
+func (s *session) info() sessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sessionInfo{
+		ID:               s.id,
+		Created:          s.created,
+		Model:            s.model,
+		PermissionMode:   s.policy.Mode,
+		Busy:             s.busy,
+		LastEventID:      len(s.events),
+		PendingApprovals: len(s.approvals),
+		Files:            append([]string{}, s.files...),
+	}
+}
+
+// start processes a message in the background and returns the id of the
+// last event before it
+func (s *session) start(content string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, errors.New("the session was closed")
+	}
+	if s.busy {
+		return 0, errors.New("a message is already being processed")
+	}
+	s.busy = true
+	after := len(s.events)
+	s.publish(streamEvent{Event: agent.Event{Type: agent.EventMessage, Role: "user", Content: content}})
+
+	go func() {
+		response, err := s.agent.ProcessMessage(content)
+		usage := s.agent.Usage()
+		result := streamEvent{
+			Event:              agent.Event{Type: eventResult, Content: response, Usage: &usage},
+			VerificationFailed: s.agent.VerificationFailed(),
+		}
+		if err != nil {
+			result.IsError = true
+			result.Error = err.Error()
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.busy = false
+		result.Files = append([]string{}, s.files...)
+		s.publish(result)
+		if s.closed {
+			s.agent.Close()
+		}
+	}()
+	return after, nil
+}
+
+// record publishes an agent event, noting the files the agent reports
+// changed
+func (s *session) record(event agent.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if event.Type == agent.EventFileChanged {
+		s.addFile(event.Path)
+	}
+	s.publish(streamEvent{Event: event})
+}
+
+func (s *session) addFile(path string) {
+	for _, f := range s.files {
+		if f == path {
+			return
+		}
+	}
+	s.files = append(s.files, path)
+}
+
+func (s *session) changedFiles() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.files...)
+}
+
+// publish adds an event and wakes the streams; mu must be held
+func (s *session) publish(event streamEvent) {
+	s.events = append(s.events, event)
+	close(s.wait)
+	s.wait = make(chan struct{})
+}
+
+// eventsAfter returns the events after the id, a channel closed when more
+// arrive, and whether the session was closed
+func (s *session) eventsAfter(id int) ([]numberedEvent, <-chan struct{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []numberedEvent
+	for i := id; i < len(s.events); i++ {
+		events = append(events, numberedEvent{id: i + 1, event: s.events[i]})
+	}
+	return events, s.wait, s.closed
+}
+
+// approve is the agent's approver: it publishes the request and waits for
+// an answer, the session to close, or the timeout
+func (s *session) approve(request agent.Event) (bool, string) {
+	pending := &approval{request: request, answer: make(chan decision, 1)}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return false, "the session was closed"
+	}
+	if pending.request.ID == "" {
+		pending.request.ID = fmt.Sprintf("call_%d", len(s.events)+1)
+	}
+	s.approvals[pending.request.ID] = pending
+	s.publish(streamEvent{Event: pending.request})
+	pending.eventID = len(s.events)
+	s.mu.Unlock()
+
+	timer := time.NewTimer(approvalTimeout)
+	defer timer.Stop()
+	var answer decision
+	select {
+	case answer = <-pending.answer:
+	case <-timer.C:
+		answer = decision{reason: fmt.Sprintf("no answer within %s", approvalTimeout)}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.approvals, pending.request.ID)
+	approved := answer.approved
+	s.publish(streamEvent{
+		Event:    agent.Event{Type: eventApprovalResolved, ID: pending.request.ID, Tool: request.Tool},
+		Approved: &approved,
+		Reason:   answer.reason,
+	})
+	return answer.approved, answer.reason
+}
+
+// answer resolves a pending approval, reporting whether there was one
+func (s *session) answer(id string, approved bool, reason string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending, ok := s.approvals[id]
+	if !ok {
+		return false
+	}
+	delete(s.approvals, id)
+	pending.answer <- decision{approved: approved, reason: reason}
+	return true
+}
+
+// pendingApprovals lists the tool calls waiting for an answer, oldest first
+func (s *session) pendingApprovals() []agent.Event {
+	s.mu.Lock()
+	var pending []*approval
+	for _, a := range s.approvals {
+		pending = append(pending, a)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].eventID < pending[j].eventID })
+	requests := make([]agent.Event, 0, len(pending))
+	for _, a := range pending {
+		requests = append(requests, a.request)
+	}
+	return requests
+}
+
+// close denies pending tool calls, ends the event streams and stops the
+// agent's MCP servers once no message is being processed
+func (s *session) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if !s.busy {
+		go s.agent.Close()
+	}
+	for id, pending := range s.approvals {
+		delete(s.approvals, id)
+		pending.answer <- decision{reason: "the session was closed"}
+	}
+	close(s.wait)
+	s.wait = make(chan struct{})
+}
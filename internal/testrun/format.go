@@ -0,0 +1,178 @@
+package testrun
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// maxListedFailures bounds how many failed tests are shown with output
+	maxListedFailures = 15
+	// maxListedSkips bounds how many skipped test names are shown
+	maxListedSkips = 10
+)
+
+// Counts returns how many tests passed, failed and were skipped. A test that
+// failed only because one of its subtests failed is not counted separately.
+func (r *Report) Counts() (passed, failed, skipped int) {
+	for _, test := range r.Tests {
+		switch test.Status {
+		case Pass:
+			passed++
+		case Fail:
+			if !r.hasFailedSubtest(test) {
+				failed++
+			}
+		case Skip:
+			skipped++
+		}
+	}
+	return passed, failed, skipped
+}
+
+// Failures lists the failed tests, leaving out parents of failed subtests
+func (r *Report) Failures() []*TestResult {
+	var failures []*TestResult
+	for _, test := range r.Tests {
+		if test.Status == Fail && !r.hasFailedSubtest(test) {
+			failures = append(failures, test)
+		}
+	}
+	return failures
+}
+
+func (r *Report) hasFailedSubtest(parent *TestResult) bool {
+	for _, test := range r.Tests {
+		if test.Status == Fail && test.Package == parent.Package && strings.HasPrefix(test.Name, parent.Name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Headline is the first line of the summary, e.g. "FAIL: 40 passed, 2 failed, ..."
+func (r *Report) Headline() string {
+	status := "PASS"
+	if !r.Passed {
+		status = "FAIL"
+	}
+	if r.TimedOut {
+		status = "TIMEOUT"
+	}
+	if r.Packages == nil && r.Tests == nil {
+		return fmt.Sprintf("%s (%s)", status, r.Duration.Round(100*time.Millisecond))
+	}
+
+	passed, failed, skipped := r.Counts()
+	tested := 0
+	for _, pkg := range r.Packages {
+		if pkg.Status != Skip || pkg.BuildFailed {
+			tested++
+		}
+	}
+	packages := "packages"
+	if tested == 1 {
+		packages = "package"
+	}
+	return fmt.Sprintf("%s: %d passed, %d failed, %d skipped in %d %s (%s)",
+		status, passed, failed, skipped, tested, packages, r.Duration.Round(100*time.Millisecond))
+}
+
+// Summary is the compact report given to the model
+func (r *Report) Summary() string {
+	var out strings.Builder
+	out.WriteString(r.Headline())
+	out.WriteString(fmt.Sprintf("\nCommand: %s (in %s)\n", r.Command, r.Dir))
+	if r.TimedOut {
+		out.WriteString("The run was killed at the timeout; the results below are incomplete.\n")
+	}
+
+	if r.Packages == nil && r.Tests == nil {
+		if len(r.Output) > 0 {
+			out.WriteString("\nOutput:\n")
+			out.WriteString(strings.Join(r.Output, "\n"))
+			out.WriteString("\n")
+		}
+		if len(r.BuildOutput) > 0 {
+			out.WriteString("\n" + strings.Join(tail(r.BuildOutput, maxOutputLines), "\n") + "\n")
+		}
+		return strings.TrimRight(out.String(), "\n")
+	}
+
+	if len(r.BuildOutput) > 0 {
+		out.WriteString("\nBuild errors:\n")
+		out.WriteString(indent(tail(r.BuildOutput, maxOutputLines*2)))
+	}
+
+	failures := r.Failures()
+	if len(failures) > 0 {
+		out.WriteString("\nFailed tests:\n")
+		for i, test := range failures {
+			if i == maxListedFailures {
+				out.WriteString(fmt.Sprintf("... and %d more failed tests\n", len(failures)-i))
+				break
+			}
+			out.WriteString(fmt.Sprintf("--- FAIL: %s (%s, %.2fs)", test.Name, test.Package, test.Elapsed))
+			if test.Location != "" {
+				out.WriteString(" at " + test.Location)
+			}
+			out.WriteString("\n")
+			out.WriteString(indent(failureOutput(test.Output)))
+		}
+	}
+
+	// Packages that failed outside any test: panics in init, TestMain, timeouts
+	for _, pkg := range r.Packages {
+		if pkg.Status != Fail || pkg.BuildFailed || r.packageHasFailedTest(pkg.ImportPath) {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("\nFAIL package %s:\n", pkg.ImportPath))
+		out.WriteString(indent(tail(pkg.Output, maxOutputLines)))
+	}
+
+	var failedBuilds []string
+	for _, pkg := range r.Packages {
+		if pkg.BuildFailed {
+			failedBuilds = append(failedBuilds, pkg.ImportPath)
+		}
+	}
+	if len(failedBuilds) > 0 {
+		out.WriteString("\nPackages that did not build: " + strings.Join(failedBuilds, ", ") + "\n")
+	}
+
+	var skips []string
+	for _, test := range r.Tests {
+		if test.Status == Skip {
+			skips = append(skips, test.Name)
+		}
+	}
+	if len(skips) > 0 {
+		if len(skips) > maxListedSkips {
+			skips = append(skips[:maxListedSkips], fmt.Sprintf("and %d more", len(skips)-maxListedSkips))
+		}
+		out.WriteString("\nSkipped: " + strings.Join(skips, ", ") + "\n")
+	}
+
+	if len(r.Tests) == 0 && len(r.BuildOutput) == 0 {
+		out.WriteString("\nNo tests ran.\n")
+	}
+	return strings.TrimRight(out.String(), "\n")
+}
+
+func (r *Report) packageHasFailedTest(importPath string) bool {
+	for _, test := range r.Tests {
+		if test.Package == importPath && test.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+func indent(lines []string) string {
+	var out strings.Builder
+	for _, line := range lines {
+		out.WriteString("    " + strings.TrimLeft(line, " \t") + "\n")
+	}
+	return out.String()
+}
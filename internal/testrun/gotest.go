@@ -0,0 +1,192 @@
+package testrun
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// testEvent is one line of go test -json output (see go doc test2json)
+type testEvent struct {
+	Action     string
+	Package    string
+	Test       string
+	Elapsed    float64
+	Output     string
+	ImportPath string // build-output and build-fail events
+}
+
+// failureLocation matches the file:line prefix of t.Error output and the
+// file:line of panic stack frames
+var failureLocation = regexp.MustCompile(`^\s*([\w./\\-]+\.go):(\d+)`)
+
+// eventParser collects go test -json events into results
+type eventParser struct {
+	packages map[string]*PackageResult
+	tests    map[string]*TestResult
+	order    []string // package import paths in the order they appeared
+	testKeys []string
+	build    []string
+	root     string // project root, for making locations relative
+	dirOf    func(importPath string) string
+}
+
+func newEventParser(root string, dirOf func(string) string) *eventParser {
+	return &eventParser{
+		packages: make(map[string]*PackageResult),
+		tests:    make(map[string]*TestResult),
+		root:     root,
+		dirOf:    dirOf,
+	}
+}
+
+// parse reads the JSON stream; lines that are not events (such as build
+// errors from older toolchains) are kept as build output
+func (p *eventParser) parse(stream []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(stream))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var event testEvent
+		if len(line) == 0 || line[0] != '{' || json.Unmarshal(line, &event) != nil {
+			if text := strings.TrimRight(string(line), " \t"); text != "" {
+				p.build = append(p.build, text)
+			}
+			continue
+		}
+		p.handle(event)
+	}
+}
+
+func (p *eventParser) handle(event testEvent) {
+	output := strings.TrimRight(event.Output, "\n")
+
+	switch event.Action {
+	case "build-output":
+		p.build = append(p.build, output)
+		return
+	case "build-fail":
+		// The import path of a test binary carries a suffix: "pkg [pkg.test]"
+		if fields := strings.Fields(event.ImportPath); len(fields) > 0 {
+			p.pkg(fields[0]).BuildFailed = true
+		}
+		return
+	}
+	if event.Package == "" {
+		return
+	}
+
+	pkg := p.pkg(event.Package)
+	if event.Test == "" {
+		switch event.Action {
+		case "output":
+			if keepPackageOutput(output) {
+				pkg.Output = append(pkg.Output, output)
+			}
+		case "pass", "fail", "skip":
+			pkg.Status = Status(event.Action)
+			pkg.Elapsed = event.Elapsed
+		}
+		return
+	}
+
+	test := p.test(event.Package, event.Test)
+	switch event.Action {
+	case "output":
+		if keepTestOutput(output) {
+			test.Output = append(test.Output, output)
+		}
+	case "pass", "fail", "skip":
+		test.Status = Status(event.Action)
+		test.Elapsed = event.Elapsed
+	}
+}
+
+func (p *eventParser) pkg(importPath string) *PackageResult {
+	pkg, ok := p.packages[importPath]
+	if !ok {
+		pkg = &PackageResult{ImportPath: importPath}
+		p.packages[importPath] = pkg
+		p.order = append(p.order, importPath)
+	}
+	return pkg
+}
+
+func (p *eventParser) test(pkg, name string) *TestResult {
+	key := pkg + "\x00" + name
+	test, ok := p.tests[key]
+	if !ok {
+		test = &TestResult{Package: pkg, Name: name}
+		p.tests[key] = test
+		p.testKeys = append(p.testKeys, key)
+	}
+	return test
+}
+
+// finish stores the results in report, locating each failure
+func (p *eventParser) finish(report *Report) {
+	for _, importPath := range p.order {
+		report.Packages = append(report.Packages, p.packages[importPath])
+	}
+	for _, key := range p.testKeys {
+		test := p.tests[key]
+		if test.Status == Fail {
+			test.Location = p.locate(test)
+		}
+		report.Tests = append(report.Tests, test)
+	}
+	report.BuildOutput = p.build
+}
+
+// locate finds the first file:line in a failed test's output that belongs to
+// the project, skipping stack frames in the standard library and dependencies
+func (p *eventParser) locate(test *TestResult) string {
+	for _, line := range test.Output {
+		match := failureLocation.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		file := filepath.ToSlash(match[1])
+		if filepath.IsAbs(match[1]) {
+			rel, err := filepath.Rel(p.root, match[1])
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			file = filepath.ToSlash(rel)
+		} else if !strings.Contains(file, "/") {
+			if dir := p.dirOf(test.Package); dir != "" && dir != "." {
+				file = path.Join(dir, file)
+			}
+		}
+		return file + ":" + match[2]
+	}
+	return ""
+}
+
+// keepTestOutput drops go test's own progress markers
+func keepTestOutput(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range []string{"=== RUN", "=== PAUSE", "=== CONT", "=== NAME", "--- PASS", "--- FAIL", "--- SKIP"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return false
+		}
+	}
+	return trimmed != ""
+}
+
+// keepPackageOutput drops the PASS/FAIL/ok summary lines of a package
+func keepPackageOutput(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "", trimmed == "PASS", trimmed == "FAIL", trimmed == "testing: warning: no tests to run":
+		return false
+	case strings.HasPrefix(trimmed, "ok "), strings.HasPrefix(trimmed, "ok\t"),
+		strings.HasPrefix(trimmed, "FAIL\t"), strings.HasPrefix(trimmed, "?   \t"), strings.HasPrefix(trimmed, "?\t"):
+		return false
+	}
+	return keepTestOutput(line)
+}
@@ -0,0 +1,238 @@
+// Package testrun runs a project's tests with the command detected for its
+// sub-project and condenses the results into a short report. Go tests run
+// with go test -json, so every test is reported as passed, failed or skipped
+// with its failure output and location; other project types report the tail
+// of their test command's output.
+package testrun
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ttli3/go-coding-agent/internal/project"
+)
+
+// DefaultTimeout applies when Options.Timeout is zero
+const DefaultTimeout = 5 * time.Minute
+
+// maxOutputLines bounds the output kept for one test, package or command
+const maxOutputLines = 20
+
+// Options selects which tests to run
+type Options struct {
+	Path    string        // file or directory inside the project; "" means the project root
+	Run     string        // only run tests matching this regular expression (Go only)
+	Timeout time.Duration // kill the run after this long
+}
+
+// Status is the outcome of a test or package
+type Status string
+
+const (
+	Pass Status = "pass"
+	Fail Status = "fail"
+	Skip Status = "skip"
+)
+
+// TestResult is the outcome of one test or subtest
+type TestResult struct {
+	Package  string
+	Name     string
+	Status   Status
+	Elapsed  float64  // seconds
+	Output   []string // what the test printed, without go test's own markers
+	Location string   // file:line of the first failure message, relative to the project root
+}
+
+// PackageResult is the outcome of one Go package
+type PackageResult struct {
+	ImportPath  string
+	Status      Status // Skip when the package has no tests
+	Elapsed     float64
+	Output      []string // package-level output, such as panics outside a test
+	BuildFailed bool
+}
+
+// Report is the condensed outcome of a test run
+type Report struct {
+	Command  string
+	Dir      string // where the command ran, relative to the project root
+	Passed   bool
+	TimedOut bool
+	Duration time.Duration
+
+	// Go runs
+	Packages    []*PackageResult
+	Tests       []*TestResult
+	BuildOutput []string
+
+	// Other project types
+	Output []string // tail of the command's combined output
+}
+
+// Run runs the tests of the sub-project containing opts.Path
+func Run(layout *project.Layout, opts Options) (*Report, error) {
+	if layout == nil {
+		return nil, fmt.Errorf("project layout is not known")
+	}
+
+	target := opts.Path
+	if target == "" {
+		target = layout.Root
+	}
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return nil, err
+	}
+	proj := layout.ProjectFor(abs)
+	if proj == nil {
+		return nil, fmt.Errorf("%s is not inside a detected project", target)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	dir := filepath.Join(layout.Root, filepath.FromSlash(proj.Path))
+	if hasKind(proj, "go") {
+		return runGo(layout, proj, dir, abs, opts)
+	}
+	if proj.Test == "" {
+		return nil, fmt.Errorf("no test command detected for %s", proj.Path)
+	}
+	if opts.Run != "" {
+		return nil, fmt.Errorf("selecting tests by name is only supported for Go projects")
+	}
+	return runCommand(proj, dir, opts)
+}
+
+// runGo runs go test -json on the packages selected by abs
+func runGo(layout *project.Layout, proj *project.SubProject, dir, abs string, opts Options) (*Report, error) {
+	patterns, err := goPatterns(proj, dir, abs)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"test", "-json"}
+	if opts.Run != "" {
+		args = append(args, "-run", opts.Run)
+	}
+	args = append(args, patterns...)
+
+	report := &Report{Command: "go " + strings.Join(args, " "), Dir: proj.Path}
+	stdout, stderr, err := execute(report, dir, opts.Timeout, "go", args...)
+
+	parser := newEventParser(layout.Root, func(importPath string) string {
+		return packageDir(proj, importPath)
+	})
+	parser.parse(stdout)
+	parser.finish(report)
+
+	if text := strings.TrimSpace(string(stderr)); text != "" {
+		report.BuildOutput = append(report.BuildOutput, strings.Split(text, "\n")...)
+	}
+	report.Passed = err == nil && !report.TimedOut
+	return report, nil
+}
+
+// goPatterns turns the target path into package patterns: a file selects its
+// package, a directory everything below it, and the project root the
+// patterns of the detected test command (which cover every module of a
+// go.work workspace)
+func goPatterns(proj *project.SubProject, dir, abs string) ([]string, error) {
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return nil, err
+	}
+	rel = filepath.ToSlash(rel)
+
+	if rel == "." {
+		if fields := strings.Fields(proj.Test); len(fields) > 2 && fields[0] == "go" && fields[1] == "test" {
+			return fields[2:], nil
+		}
+		return []string{"./..."}, nil
+	}
+	if strings.HasSuffix(rel, ".go") {
+		return []string{"./" + filepath.ToSlash(filepath.Dir(rel))}, nil
+	}
+	return []string{"./" + rel + "/..."}, nil
+}
+
+// packageDir maps an import path to its directory relative to the project
+// root, or "" when the module path is unknown
+func packageDir(proj *project.SubProject, importPath string) string {
+	if proj.Name == "" || (importPath != proj.Name && !strings.HasPrefix(importPath, proj.Name+"/")) {
+		return ""
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(importPath, proj.Name), "/")
+	return filepath.ToSlash(filepath.Join(proj.Path, rel))
+}
+
+// runCommand runs the detected test command of a non-Go project
+func runCommand(proj *project.SubProject, dir string, opts Options) (*Report, error) {
+	report := &Report{Command: proj.Test, Dir: proj.Path}
+	stdout, stderr, err := execute(report, dir, opts.Timeout, "sh", "-c", proj.Test+" 2>&1")
+
+	output := strings.TrimSpace(string(stdout) + string(stderr))
+	if output != "" {
+		report.Output = tail(strings.Split(output, "\n"), maxOutputLines*2)
+	}
+	report.Passed = err == nil && !report.TimedOut
+	return report, nil
+}
+
+// execute runs a command in dir, recording how long it took and whether it
+// timed out
+func execute(report *Report, dir string, timeout time.Duration, name string, args ...string) ([]byte, []byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	report.Duration = time.Since(start)
+	report.TimedOut = ctx.Err() == context.DeadlineExceeded
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+func hasKind(proj *project.SubProject, kind string) bool {
+	for _, k := range proj.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// failureOutput keeps the start of a panic, where the message and the
+// failing frame are, and otherwise the last lines before the failure
+func failureOutput(lines []string) []string {
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "panic:") {
+			if len(lines)-i > maxOutputLines {
+				return append(lines[i:i+maxOutputLines:i+maxOutputLines], fmt.Sprintf("... %d more lines", len(lines)-i-maxOutputLines))
+			}
+			return lines[i:]
+		}
+	}
+	return tail(lines, maxOutputLines)
+}
+
+// tail keeps the last n lines
+func tail(lines []string, n int) []string {
+	if len(lines) <= n {
+		return lines
+	}
+	kept := append([]string{fmt.Sprintf("... %d earlier lines omitted", len(lines)-n)}, lines[len(lines)-n:]...)
+	return kept
+}
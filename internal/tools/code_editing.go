@@ -3,7 +3,6 @@ package tools
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -125,6 +124,10 @@ func (t *SearchCodeTool) Name() string {
 	return "search_code"
 }
 
+func (t *SearchCodeTool) ReadOnly() bool {
+	return true
+}
+
 func (t *SearchCodeTool) Description() string {
 	return "Search for code patterns in a file"
 }
@@ -301,134 +304,3 @@ func (t *ReplaceContentTool) Schema() ToolSchema {
 		Required: []string{"path", "old_pattern", "new_content"},
 	}
 }
-
-// GrepSearchTool searches for patterns across multiple files
-type GrepSearchTool struct{}
-
-func (t *GrepSearchTool) Name() string {
-	return "grep_search"
-}
-
-func (t *GrepSearchTool) Description() string {
-	return "Search for patterns across multiple files in a directory"
-}
-
-func (t *GrepSearchTool) Execute(args map[string]interface{}) (string, error) {
-	path, ok := args["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("path parameter is required and must be a string")
-	}
-
-	pattern, ok := args["pattern"].(string)
-	if !ok {
-		return "", fmt.Errorf("pattern parameter is required and must be a string")
-	}
-
-	filePattern := "*"
-	if val, exists := args["file_pattern"]; exists {
-		if fpVal, ok := val.(string); ok {
-			filePattern = fpVal
-		}
-	}
-
-	useRegex := false
-	if val, exists := args["regex"]; exists {
-		if regexVal, ok := val.(bool); ok {
-			useRegex = regexVal
-		}
-	}
-
-	var matches []string
-	var regex *regexp.Regexp
-	var err error
-
-	if useRegex {
-		regex, err = regexp.Compile(pattern)
-		if err != nil {
-			return "", fmt.Errorf("invalid regex pattern: %w", err)
-		}
-	}
-
-	err = filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Continue walking
-		}
-
-		if info.IsDir() {
-			return nil
-		}
-
-		// Check if file matches the file pattern
-		matched, err := filepath.Match(filePattern, info.Name())
-		if err != nil || !matched {
-			return nil
-		}
-
-		// Skip binary files (simple heuristic)
-		if strings.Contains(info.Name(), ".exe") || strings.Contains(info.Name(), ".bin") {
-			return nil
-		}
-
-		content, err := os.ReadFile(filePath)
-		if err != nil {
-			return nil // Continue with other files
-		}
-
-		lines := strings.Split(string(content), "\n")
-		for i, line := range lines {
-			var lineMatched bool
-			if useRegex {
-				lineMatched = regex.MatchString(line)
-			} else {
-				lineMatched = strings.Contains(line, pattern)
-			}
-
-			if lineMatched {
-				matches = append(matches, fmt.Sprintf("%s:%d: %s", filePath, i+1, strings.TrimSpace(line)))
-			}
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		return "", fmt.Errorf("failed to search files: %w", err)
-	}
-
-	if len(matches) == 0 {
-		return fmt.Sprintf("No matches found for pattern '%s' in %s", pattern, path), nil
-	}
-
-	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Found %d matches for '%s':\n", len(matches), pattern))
-	for _, match := range matches {
-		result.WriteString(fmt.Sprintf("  %s\n", match))
-	}
-
-	return result.String(), nil
-}
-
-func (t *GrepSearchTool) Schema() ToolSchema {
-	return ToolSchema{
-		Type: "object",
-		Properties: map[string]PropertyDefinition{
-			"path": {
-				Type:        "string",
-				Description: "Directory path to search in",
-			},
-			"pattern": {
-				Type:        "string",
-				Description: "Pattern to search for",
-			},
-			"file_pattern": {
-				Type:        "string",
-				Description: "File name pattern to limit search (default: *)",
-			},
-			"regex": {
-				Type:        "boolean",
-				Description: "Whether to treat pattern as a regular expression (default: false)",
-			},
-		},
-		Required: []string{"path", "pattern"},
-	}
-}
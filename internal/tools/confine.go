@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pathArgs are the tool arguments that name files or directories
+var pathArgs = []string{"path", "working_dir", "filename"}
+
+// SetRoot confines the path arguments of every tool to root, following
+// symbolic links, or lifts the confinement when root is empty
+func (r *Registry) SetRoot(root string) error {
+	if root == "" {
+		r.root = ""
+		return nil
+	}
+	resolved, err := resolvePath(root)
+	if err != nil {
+		return fmt.Errorf("invalid root %s: %w", root, err)
+	}
+	r.root = resolved
+	return nil
+}
+
+// Root returns the directory path arguments are confined to, or ""
+func (r *Registry) Root() string {
+	return r.root
+}
+
+// checkPaths refuses path arguments that resolve outside the root
+func (r *Registry) checkPaths(args map[string]interface{}) error {
+	for _, name := range pathArgs {
+		path, ok := args[name].(string)
+		if !ok || path == "" {
+			continue
+		}
+		resolved, err := resolvePath(path)
+		if err != nil {
+			return fmt.Errorf("invalid %s %s: %w", name, path, err)
+		}
+		rel, err := filepath.Rel(r.root, resolved)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s %s is outside the workspace %s", name, path, r.root)
+		}
+	}
+	return nil
+}
+
+// resolvePath makes path absolute and resolves symbolic links in the part
+// of it that exists, so files about to be created can be checked too
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	existing, missing := abs, ""
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return abs, nil
+		}
+		missing = filepath.Join(filepath.Base(existing), missing)
+		existing = parent
+	}
+}
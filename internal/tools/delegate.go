@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Delegator runs investigations in isolated sub-agents
+type Delegator interface {
+	Delegate(tasks []string) (string, error)
+}
+
+// DelegateTool hands self-contained investigations to sub-agents and returns
+// only their reports
+type DelegateTool struct {
+	delegator Delegator
+}
+
+// NewDelegateTool creates a delegate tool backed by delegator
+func NewDelegateTool(delegator Delegator) *DelegateTool {
+	return &DelegateTool{delegator: delegator}
+}
+
+func (t *DelegateTool) Name() string {
+	return "delegate"
+}
+
+func (t *DelegateTool) ReadOnly() bool {
+	return true
+}
+
+func (t *DelegateTool) Description() string {
+	return "Hand self-contained investigations (e.g. 'find everywhere sessions are saved and how write errors are handled') " +
+		"to sub-agents that have read-only tools and their own context. Tasks run concurrently and only each final report " +
+		"comes back, which keeps large searches out of your context. Write every task so it can be done without seeing this conversation"
+}
+
+func (t *DelegateTool) Execute(args map[string]interface{}) (string, error) {
+	var tasks []string
+	if raw, ok := args["tasks"].([]interface{}); ok {
+		for _, entry := range raw {
+			if task, ok := entry.(string); ok && strings.TrimSpace(task) != "" {
+				tasks = append(tasks, strings.TrimSpace(task))
+			}
+		}
+	}
+	// Accept a single task too, as models sometimes send one
+	if task := stringArg(args, "task", ""); task != "" {
+		tasks = append(tasks, task)
+	}
+
+	if len(tasks) == 0 {
+		return "", fmt.Errorf("tasks must list at least one investigation")
+	}
+	return t.delegator.Delegate(tasks)
+}
+
+func (t *DelegateTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"tasks": {
+				Type:        "array",
+				Description: "Independent investigations, each with what to find out and what to report",
+				Items:       &PropertyDefinition{Type: "string"},
+			},
+		},
+		Required: []string{"tasks"},
+	}
+}
@@ -14,6 +14,10 @@ func (t *DiffTool) Name() string {
 	return "show_diff"
 }
 
+func (t *DiffTool) ReadOnly() bool {
+	return true
+}
+
 func (t *DiffTool) Description() string {
 	return "Show a diff view between two versions of a file or content"
 }
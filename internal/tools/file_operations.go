@@ -17,6 +17,10 @@ func (t *ReadFileTool) Name() string {
 	return "read_file"
 }
 
+func (t *ReadFileTool) ReadOnly() bool {
+	return true
+}
+
 func (t *ReadFileTool) Description() string {
 	return "Read the contents of a file"
 }
@@ -119,6 +123,10 @@ func (t *ListDirectoryTool) Name() string {
 	return "list_directory"
 }
 
+func (t *ListDirectoryTool) ReadOnly() bool {
+	return true
+}
+
 func (t *ListDirectoryTool) Description() string {
 	return "List the contents of a directory"
 }
@@ -175,6 +183,10 @@ func (t *FindFilesTool) Name() string {
 	return "find_files"
 }
 
+func (t *FindFilesTool) ReadOnly() bool {
+	return true
+}
+
 func (t *FindFilesTool) Description() string {
 	return "Find files matching a pattern recursively in directories and subdirectories"
 }
@@ -0,0 +1,531 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ttli3/go-coding-agent/internal/git"
+)
+
+// maxGitOutputBytes caps how much git output is handed back to the model
+const maxGitOutputBytes = 32 * 1024
+
+// runGit runs a read-only git subcommand in dir and returns its stdout.
+// Git tools never mutate the repository, so unlike run_command they do not
+// ask for confirmation.
+func runGit(dir string, args ...string) (string, error) {
+	return git.Run(dir, args...)
+}
+
+// capGitOutput truncates output to maxGitOutputBytes on a line boundary
+func capGitOutput(output string) string {
+	if len(output) <= maxGitOutputBytes {
+		return output
+	}
+
+	cut := strings.LastIndex(output[:maxGitOutputBytes], "\n")
+	if cut <= 0 {
+		cut = maxGitOutputBytes
+	}
+	remaining := strings.Count(output[cut:], "\n")
+	return fmt.Sprintf("%s\n... output truncated (%d more lines, %d bytes total)", output[:cut], remaining, len(output))
+}
+
+// stringArg returns an optional string argument or the fallback
+func stringArg(args map[string]interface{}, key, fallback string) string {
+	if val, ok := args[key].(string); ok && val != "" {
+		return val
+	}
+	return fallback
+}
+
+// refArg returns the optional ref argument or the fallback. Refs starting
+// with "-" are refused so they can't be taken as options such as --output.
+func refArg(args map[string]interface{}, fallback string) (string, error) {
+	ref := stringArg(args, "ref", fallback)
+	if strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("invalid ref %q: refs cannot start with '-'", ref)
+	}
+	return ref, nil
+}
+
+// boolArg returns an optional boolean argument or false
+func boolArg(args map[string]interface{}, key string) bool {
+	val, _ := args[key].(bool)
+	return val
+}
+
+// intArg returns an optional numeric argument or the fallback
+func intArg(args map[string]interface{}, key string, fallback int) int {
+	if val, ok := args[key].(float64); ok {
+		return int(val)
+	}
+	return fallback
+}
+
+// GitStatusTool reports the working tree status
+type GitStatusTool struct{}
+
+func (t *GitStatusTool) Name() string {
+	return "git_status"
+}
+
+func (t *GitStatusTool) ReadOnly() bool {
+	return true
+}
+
+func (t *GitStatusTool) Description() string {
+	return "Show the git branch and staged, unstaged and untracked files (read-only)"
+}
+
+func (t *GitStatusTool) Execute(args map[string]interface{}) (string, error) {
+	dir := stringArg(args, "working_dir", "")
+
+	output, err := runGit(dir, "status", "--porcelain=v1", "--branch")
+	if err != nil {
+		return "", err
+	}
+
+	var branch string
+	var staged, unstaged, untracked, conflicted []string
+
+	for _, line := range strings.Split(output, "\n") {
+		if len(line) < 3 {
+			continue
+		}
+		if strings.HasPrefix(line, "## ") {
+			branch = strings.TrimPrefix(line, "## ")
+			continue
+		}
+
+		x, y, path := line[0], line[1], line[3:]
+		switch {
+		case x == '?' && y == '?':
+			untracked = append(untracked, path)
+		case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+			conflicted = append(conflicted, path)
+		default:
+			if x != ' ' {
+				staged = append(staged, fmt.Sprintf("%s %s", describeGitStatus(x), path))
+			}
+			if y != ' ' {
+				unstaged = append(unstaged, fmt.Sprintf("%s %s", describeGitStatus(y), path))
+			}
+		}
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Branch: %s\n", branch))
+
+	sections := []struct {
+		title string
+		files []string
+	}{
+		{"Conflicts", conflicted},
+		{"Staged", staged},
+		{"Unstaged", unstaged},
+		{"Untracked", untracked},
+	}
+
+	clean := true
+	for _, section := range sections {
+		if len(section.files) == 0 {
+			continue
+		}
+		clean = false
+		result.WriteString(fmt.Sprintf("%s (%d):\n", section.title, len(section.files)))
+		for _, file := range section.files {
+			result.WriteString(fmt.Sprintf("  %s\n", file))
+		}
+	}
+
+	if clean {
+		result.WriteString("Working tree clean\n")
+	}
+
+	return capGitOutput(result.String()), nil
+}
+
+func (t *GitStatusTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"working_dir": {
+				Type:        "string",
+				Description: "Directory inside the repository (default: current directory)",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func describeGitStatus(code byte) string {
+	switch code {
+	case 'M':
+		return "modified:"
+	case 'A':
+		return "added:"
+	case 'D':
+		return "deleted:"
+	case 'R':
+		return "renamed:"
+	case 'C':
+		return "copied:"
+	case 'T':
+		return "typechange:"
+	default:
+		return string(code) + ":"
+	}
+}
+
+// GitDiffTool shows staged, unstaged or ref-based diffs
+type GitDiffTool struct{}
+
+func (t *GitDiffTool) Name() string {
+	return "git_diff"
+}
+
+func (t *GitDiffTool) ReadOnly() bool {
+	return true
+}
+
+func (t *GitDiffTool) Description() string {
+	return "Show git diff of unstaged changes, staged changes, or against a ref (read-only)"
+}
+
+func (t *GitDiffTool) Execute(args map[string]interface{}) (string, error) {
+	dir := stringArg(args, "working_dir", "")
+	ref, err := refArg(args, "")
+	if err != nil {
+		return "", err
+	}
+	path := stringArg(args, "path", "")
+	staged := boolArg(args, "staged")
+
+	gitArgs := []string{"diff", "--no-color"}
+	if boolArg(args, "stat") {
+		gitArgs = append(gitArgs, "--stat")
+	}
+	if staged {
+		gitArgs = append(gitArgs, "--cached")
+	}
+	if ref != "" {
+		gitArgs = append(gitArgs, ref)
+	}
+	if path != "" {
+		gitArgs = append(gitArgs, "--", path)
+	}
+
+	output, err := runGit(dir, gitArgs...)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(output) == "" {
+		target := "working tree"
+		if staged {
+			target = "index"
+		}
+		if ref != "" {
+			target += " against " + ref
+		}
+		return fmt.Sprintf("No differences in %s", target), nil
+	}
+
+	return capGitOutput(output), nil
+}
+
+func (t *GitDiffTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"staged": {
+				Type:        "boolean",
+				Description: "Show staged changes instead of unstaged ones (default: false)",
+			},
+			"ref": {
+				Type:        "string",
+				Description: "Commit, branch or range to diff against, e.g. HEAD~1 or main...HEAD (optional)",
+			},
+			"path": {
+				Type:        "string",
+				Description: "Limit the diff to this file or directory (optional)",
+			},
+			"stat": {
+				Type:        "boolean",
+				Description: "Only show a per-file summary of changed lines (default: false)",
+			},
+			"working_dir": {
+				Type:        "string",
+				Description: "Directory inside the repository (default: current directory)",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+// GitLogTool lists recent commits
+type GitLogTool struct{}
+
+func (t *GitLogTool) Name() string {
+	return "git_log"
+}
+
+func (t *GitLogTool) ReadOnly() bool {
+	return true
+}
+
+func (t *GitLogTool) Description() string {
+	return "Show recent git commits, optionally limited to a ref or path (read-only)"
+}
+
+func (t *GitLogTool) Execute(args map[string]interface{}) (string, error) {
+	dir := stringArg(args, "working_dir", "")
+	ref, err := refArg(args, "")
+	if err != nil {
+		return "", err
+	}
+	path := stringArg(args, "path", "")
+	maxCount := intArg(args, "max_count", 20)
+	if maxCount <= 0 || maxCount > 200 {
+		maxCount = 20
+	}
+
+	gitArgs := []string{"log", "--no-color", "--date=short",
+		"--pretty=format:%h\x1f%an\x1f%ad\x1f%s", "-n", strconv.Itoa(maxCount)}
+	if ref != "" {
+		gitArgs = append(gitArgs, ref)
+	}
+	if path != "" {
+		gitArgs = append(gitArgs, "--", path)
+	}
+
+	output, err := runGit(dir, gitArgs...)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return "No commits found", nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Last %d commits:\n", len(lines)))
+	for _, line := range lines {
+		fields := strings.SplitN(line, "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		result.WriteString(fmt.Sprintf("  %s %s %-16s %s\n", fields[0], fields[2], fields[1], fields[3]))
+	}
+
+	return capGitOutput(result.String()), nil
+}
+
+func (t *GitLogTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"max_count": {
+				Type:        "number",
+				Description: "Maximum number of commits to show (default: 20, max: 200)",
+			},
+			"ref": {
+				Type:        "string",
+				Description: "Branch, tag or range to log, e.g. main..HEAD (optional)",
+			},
+			"path": {
+				Type:        "string",
+				Description: "Only show commits touching this file or directory (optional)",
+			},
+			"working_dir": {
+				Type:        "string",
+				Description: "Directory inside the repository (default: current directory)",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+// GitBlameTool shows who last changed each line of a file
+type GitBlameTool struct{}
+
+func (t *GitBlameTool) Name() string {
+	return "git_blame"
+}
+
+func (t *GitBlameTool) ReadOnly() bool {
+	return true
+}
+
+func (t *GitBlameTool) Description() string {
+	return "Show the commit, author and date that last changed each line of a file (read-only)"
+}
+
+func (t *GitBlameTool) Execute(args map[string]interface{}) (string, error) {
+	path, ok := args["path"].(string)
+	if !ok {
+		return "", fmt.Errorf("path parameter is required and must be a string")
+	}
+
+	dir := stringArg(args, "working_dir", "")
+	startLine := intArg(args, "start_line", 0)
+	endLine := intArg(args, "end_line", 0)
+
+	gitArgs := []string{"blame", "--line-porcelain"}
+	if startLine > 0 {
+		if endLine < startLine {
+			endLine = startLine + 49
+		}
+		gitArgs = append(gitArgs, "-L", fmt.Sprintf("%d,%d", startLine, endLine))
+	}
+	ref, err := refArg(args, "")
+	if err != nil {
+		return "", err
+	}
+	if ref != "" {
+		gitArgs = append(gitArgs, ref)
+	}
+	gitArgs = append(gitArgs, "--", path)
+
+	output, err := runGit(dir, gitArgs...)
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	var hash, author, date string
+	var lineNum int
+
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			result.WriteString(fmt.Sprintf("%5d %s %s %-16s %s\n", lineNum, hash, date, author, line[1:]))
+		case strings.HasPrefix(line, "author "):
+			author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			date = formatGitTimestamp(strings.TrimPrefix(line, "author-time "))
+		default:
+			// Header lines look like "<40-char sha> <orig line> <final line> [count]"
+			fields := strings.Fields(line)
+			if len(fields) >= 3 && len(fields[0]) == 40 {
+				hash = fields[0][:8]
+				lineNum, _ = strconv.Atoi(fields[2])
+			}
+		}
+	}
+
+	if result.Len() == 0 {
+		return fmt.Sprintf("No blame information for %s", path), nil
+	}
+
+	return capGitOutput(result.String()), nil
+}
+
+func (t *GitBlameTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"path": {
+				Type:        "string",
+				Description: "Path to the file to blame",
+			},
+			"start_line": {
+				Type:        "number",
+				Description: "First line to blame (1-based, optional)",
+			},
+			"end_line": {
+				Type:        "number",
+				Description: "Last line to blame (inclusive, default: start_line + 49)",
+			},
+			"ref": {
+				Type:        "string",
+				Description: "Blame the file as of this commit (optional)",
+			},
+			"working_dir": {
+				Type:        "string",
+				Description: "Directory inside the repository (default: current directory)",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+// GitShowTool shows a commit or a file at a given commit
+type GitShowTool struct{}
+
+func (t *GitShowTool) Name() string {
+	return "git_show"
+}
+
+func (t *GitShowTool) ReadOnly() bool {
+	return true
+}
+
+func (t *GitShowTool) Description() string {
+	return "Show a commit's message and diff, or a file's contents at a given commit (read-only)"
+}
+
+func (t *GitShowTool) Execute(args map[string]interface{}) (string, error) {
+	dir := stringArg(args, "working_dir", "")
+	ref, err := refArg(args, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	path := stringArg(args, "path", "")
+
+	var gitArgs []string
+	if path != "" {
+		// Show the file as it was at ref
+		gitArgs = []string{"show", "--no-color", fmt.Sprintf("%s:%s", ref, path)}
+	} else {
+		gitArgs = []string{"show", "--no-color", "--date=short"}
+		if boolArg(args, "stat") {
+			gitArgs = append(gitArgs, "--stat")
+		}
+		gitArgs = append(gitArgs, ref)
+	}
+
+	output, err := runGit(dir, gitArgs...)
+	if err != nil {
+		return "", err
+	}
+
+	return capGitOutput(output), nil
+}
+
+func (t *GitShowTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"ref": {
+				Type:        "string",
+				Description: "Commit, branch or tag to show (default: HEAD)",
+			},
+			"path": {
+				Type:        "string",
+				Description: "Show this file's contents at ref instead of the commit (optional, relative to repository root)",
+			},
+			"stat": {
+				Type:        "boolean",
+				Description: "Only show the commit message and a per-file summary (default: false)",
+			},
+			"working_dir": {
+				Type:        "string",
+				Description: "Directory inside the repository (default: current directory)",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func formatGitTimestamp(unix string) string {
+	secs, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return unix
+	}
+	return time.Unix(secs, 0).Format("2006-01-02")
+}
@@ -0,0 +1,758 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/ttli3/go-coding-agent/internal/walker"
+)
+
+// maxSymbolResults caps how many symbols or references are listed
+const maxSymbolResults = 200
+
+// goPackageCache keeps type-checked packages between tool calls and reloads
+// them only when a Go file under the root is added, removed or modified.
+// Only the packages under the root are type-checked from source; imports,
+// including the module's own packages, come from the build cache's export
+// data, which keeps reloads after an edit fast.
+var goPackageCache struct {
+	sync.Mutex
+	root        string
+	fingerprint string
+	pkgs        []*packages.Package
+}
+
+// RegisterGoTools adds the Go symbol navigation tools to a registry
+func RegisterGoTools(registry *Registry) {
+	registry.Register(&FindDefinitionTool{})
+	registry.Register(&FindReferencesTool{})
+	registry.Register(&ListSymbolsTool{})
+	registry.Register(&OutlineFileTool{})
+}
+
+// loadGoPackages type-checks every package under root, reusing the cached result
+// when nothing changed
+func loadGoPackages(root string) ([]*packages.Package, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := goSourceFingerprint(absRoot)
+
+	goPackageCache.Lock()
+	defer goPackageCache.Unlock()
+
+	if goPackageCache.root == absRoot && goPackageCache.fingerprint == fingerprint {
+		return goPackageCache.pkgs, nil
+	}
+
+	mode := packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+		packages.NeedTypes | packages.NeedTypesInfo
+	if !exportDataReadable() {
+		mode |= packages.NeedImports | packages.NeedDeps
+	}
+	cfg := &packages.Config{
+		Mode:  mode,
+		Dir:   absRoot,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Go packages: %w", err)
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no Go packages found in %s", absRoot)
+	}
+
+	goPackageCache.root = absRoot
+	goPackageCache.fingerprint = fingerprint
+	goPackageCache.pkgs = pkgs
+	return pkgs, nil
+}
+
+// exportDataReadable reports whether go/packages can read the export data
+// written by the installed toolchain. A toolchain newer than x/tools may use
+// a format it can't read, and go/packages exits the process when a package
+// imports one it couldn't load, so dependencies are then type-checked from
+// source instead (NeedDeps), which is slower but always works.
+var exportDataReadable = sync.OnceValue(func() bool {
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedTypes}, "errors")
+	return err == nil && len(pkgs) == 1 && len(pkgs[0].Errors) == 0 &&
+		pkgs[0].Types != nil && pkgs[0].Types.Complete()
+})
+
+// goSourceFingerprint summarizes the set of Go files and their modification times
+func goSourceFingerprint(root string) string {
+	count := 0
+	var latest time.Time
+	walker.Walk(root, walker.Options{}, func(path, rel string, d fs.DirEntry) error {
+		if !strings.HasSuffix(rel, ".go") && rel != "go.mod" {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			count++
+			if info.ModTime().After(latest) {
+				latest = info.ModTime()
+			}
+		}
+		return nil
+	})
+	return fmt.Sprintf("%d:%d", count, latest.UnixNano())
+}
+
+// goSymbolMatch is an object found by name along with the package that defines it
+type goSymbolMatch struct {
+	obj types.Object
+	pkg *packages.Package
+}
+
+// findGoObjects resolves "Name", "Type.Member" or "pkg.Name" to matching objects
+func findGoObjects(pkgs []*packages.Package, symbol string) []goSymbolMatch {
+	var matches []goSymbolMatch
+	seen := make(map[string]bool)
+
+	qualifier, name := "", symbol
+	if dot := strings.LastIndex(symbol, "."); dot >= 0 {
+		qualifier, name = symbol[:dot], symbol[dot+1:]
+	}
+
+	add := func(obj types.Object, pkg *packages.Package) {
+		if obj == nil {
+			return
+		}
+		key := pkg.Fset.Position(obj.Pos()).String()
+		if !seen[key] {
+			seen[key] = true
+			matches = append(matches, goSymbolMatch{obj: obj, pkg: pkg})
+		}
+	}
+
+	// Only the module's own packages are searched; dependencies are loaded for
+	// type information but are not part of the project
+	for _, pkg := range pkgs {
+		if pkg.Types == nil || pkg.TypesInfo == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+
+		if qualifier == "" || qualifier == pkg.Name || qualifier == pkg.PkgPath {
+			add(scope.Lookup(name), pkg)
+		}
+
+		// Methods and fields, either on the named type or on any type
+		if qualifier == "" || qualifier != pkg.Name {
+			for _, typeName := range scope.Names() {
+				if qualifier != "" && typeName != qualifier {
+					continue
+				}
+				tn, ok := scope.Lookup(typeName).(*types.TypeName)
+				if !ok {
+					continue
+				}
+				recv := tn.Type()
+				if !types.IsInterface(recv) {
+					recv = types.NewPointer(recv)
+				}
+				obj, _, _ := types.LookupFieldOrMethod(recv, true, pkg.Types, name)
+				if obj != nil && obj.Pkg() == pkg.Types && (qualifier != "" || isMemberOf(obj, tn)) {
+					add(obj, pkg)
+				}
+			}
+		}
+	}
+
+	return matches
+}
+
+// isMemberOf reports whether obj is declared directly on the named type tn
+// rather than promoted from an embedded field
+func isMemberOf(obj types.Object, tn *types.TypeName) bool {
+	if fn, ok := obj.(*types.Func); ok {
+		if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+			return namedTypeName(sig.Recv().Type()) == tn.Name()
+		}
+	}
+	if v, ok := obj.(*types.Var); ok && v.IsField() {
+		return fieldOwner(v) == tn.Name()
+	}
+	return false
+}
+
+// goObjectKey identifies an object across packages, whether it was loaded from
+// source or from export data
+func goObjectKey(obj types.Object) string {
+	if obj == nil || obj.Pkg() == nil {
+		return ""
+	}
+	prefix := obj.Pkg().Path() + "."
+
+	switch o := obj.(type) {
+	case *types.Func:
+		if sig, ok := o.Type().(*types.Signature); ok && sig.Recv() != nil {
+			return prefix + namedTypeName(sig.Recv().Type()) + "." + o.Name()
+		}
+	case *types.Var:
+		if o.IsField() {
+			return prefix + fieldOwner(o) + "." + o.Name()
+		}
+	}
+
+	if obj.Parent() == obj.Pkg().Scope() {
+		return prefix + obj.Name()
+	}
+	// Local objects are only referenced from their own package
+	return fmt.Sprintf("%s%s@%d", prefix, obj.Name(), obj.Pos())
+}
+
+// fieldOwner returns the name of the package-level struct type declaring field v
+func fieldOwner(v *types.Var) string {
+	scope := v.Pkg().Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		st, ok := tn.Type().Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			if st.Field(i) == v {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+func namedTypeName(t types.Type) string {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return t.String()
+}
+
+// describeGoObject formats an object's kind and signature relative to its package.
+// Struct and interface bodies are summarized rather than printed in full.
+func describeGoObject(obj types.Object) string {
+	qualifier := func(p *types.Package) string {
+		if p == obj.Pkg() {
+			return ""
+		}
+		return p.Name()
+	}
+
+	if tn, ok := obj.(*types.TypeName); ok && !tn.IsAlias() {
+		switch u := tn.Type().Underlying().(type) {
+		case *types.Struct:
+			return fmt.Sprintf("type %s struct (%d fields)", tn.Name(), u.NumFields())
+		case *types.Interface:
+			return fmt.Sprintf("type %s interface (%d methods)", tn.Name(), u.NumMethods())
+		}
+	}
+	return types.ObjectString(obj, qualifier)
+}
+
+// relPosition formats a position as file:line relative to the working directory
+func relPosition(pos token.Position) string {
+	filename := pos.Filename
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			filename = rel
+		}
+	}
+	return fmt.Sprintf("%s:%d", filename, pos.Line)
+}
+
+// FindDefinitionTool locates where a Go symbol is declared
+type FindDefinitionTool struct{}
+
+func (t *FindDefinitionTool) Name() string {
+	return "find_definition"
+}
+
+func (t *FindDefinitionTool) ReadOnly() bool {
+	return true
+}
+
+func (t *FindDefinitionTool) Description() string {
+	return "Find where a Go symbol (function, type, method, field, const or var) is defined, with its signature"
+}
+
+func (t *FindDefinitionTool) Execute(args map[string]interface{}) (string, error) {
+	symbol, ok := args["symbol"].(string)
+	if !ok || symbol == "" {
+		return "", fmt.Errorf("symbol parameter is required and must be a string")
+	}
+
+	pkgs, err := loadGoPackages(stringArg(args, "path", "."))
+	if err != nil {
+		return "", err
+	}
+
+	matches := findGoObjects(pkgs, symbol)
+	if len(matches) == 0 {
+		return fmt.Sprintf("No definition found for '%s'", symbol), nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d definitions for '%s':\n", len(matches), symbol))
+	for _, match := range matches {
+		pos := match.pkg.Fset.Position(match.obj.Pos())
+		result.WriteString(fmt.Sprintf("  %s: %s\n", relPosition(pos), describeGoObject(match.obj)))
+		if doc := goDocSummary(match.pkg, match.obj); doc != "" {
+			result.WriteString(fmt.Sprintf("      // %s\n", doc))
+		}
+	}
+
+	return result.String(), nil
+}
+
+// goDocSummary returns the first line of the doc comment for a declaration
+func goDocSummary(pkg *packages.Package, obj types.Object) string {
+	declares := func(names ...*ast.Ident) bool {
+		for _, name := range names {
+			if name.Pos() == obj.Pos() {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, file := range pkg.Syntax {
+		if obj.Pos() < file.Pos() || obj.Pos() > file.End() {
+			continue
+		}
+
+		var doc *ast.CommentGroup
+		ast.Inspect(file, func(n ast.Node) bool {
+			if n == nil || doc != nil || obj.Pos() < n.Pos() || obj.Pos() > n.End() {
+				return false
+			}
+			switch decl := n.(type) {
+			case *ast.FuncDecl:
+				if declares(decl.Name) {
+					doc = decl.Doc
+				}
+			case *ast.GenDecl:
+				// A lone spec's comment is attached to the declaration
+				if decl.Lparen == token.NoPos {
+					doc = decl.Doc
+					return doc == nil
+				}
+			case *ast.TypeSpec:
+				if declares(decl.Name) {
+					doc = decl.Doc
+				}
+			case *ast.ValueSpec:
+				if declares(decl.Names...) {
+					doc = decl.Doc
+				}
+			case *ast.Field:
+				if declares(decl.Names...) {
+					doc = decl.Doc
+				}
+			}
+			return true
+		})
+		if doc != nil {
+			return strings.SplitN(strings.TrimSpace(doc.Text()), "\n", 2)[0]
+		}
+	}
+	return ""
+}
+
+func (t *FindDefinitionTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"symbol": {
+				Type:        "string",
+				Description: "Symbol name, optionally qualified: Name, Type.Method, Type.Field or pkg.Name",
+			},
+			"path": {
+				Type:        "string",
+				Description: "Module directory to search (default: current directory)",
+			},
+		},
+		Required: []string{"symbol"},
+	}
+}
+
+// FindReferencesTool lists every use of a Go symbol
+type FindReferencesTool struct{}
+
+func (t *FindReferencesTool) Name() string {
+	return "find_references"
+}
+
+func (t *FindReferencesTool) ReadOnly() bool {
+	return true
+}
+
+func (t *FindReferencesTool) Description() string {
+	return "Find all references to a Go symbol across the module, using type information rather than text search"
+}
+
+func (t *FindReferencesTool) Execute(args map[string]interface{}) (string, error) {
+	symbol, ok := args["symbol"].(string)
+	if !ok || symbol == "" {
+		return "", fmt.Errorf("symbol parameter is required and must be a string")
+	}
+	maxResults := intArg(args, "max_results", maxSymbolResults)
+	if maxResults <= 0 {
+		maxResults = maxSymbolResults
+	}
+
+	pkgs, err := loadGoPackages(stringArg(args, "path", "."))
+	if err != nil {
+		return "", err
+	}
+
+	targets := make(map[string]string)
+	for _, match := range findGoObjects(pkgs, symbol) {
+		targets[goObjectKey(match.obj)] = describeGoObject(match.obj)
+	}
+	if len(targets) == 0 {
+		return fmt.Sprintf("No definition found for '%s'", symbol), nil
+	}
+
+	type reference struct {
+		pos  token.Position
+		line string
+	}
+	var refs []reference
+	seen := make(map[string]bool)
+
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for ident, obj := range pkg.TypesInfo.Uses {
+			if _, ok := targets[goObjectKey(obj)]; !ok {
+				continue
+			}
+			pos := pkg.Fset.Position(ident.Pos())
+			key := pos.String()
+			if seen[key] {
+				continue // test variants of a package share files
+			}
+			seen[key] = true
+			refs = append(refs, reference{pos: pos, line: sourceLine(pos.Filename, pos.Line)})
+		}
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].pos.Filename != refs[j].pos.Filename {
+			return refs[i].pos.Filename < refs[j].pos.Filename
+		}
+		return refs[i].pos.Line < refs[j].pos.Line
+	})
+
+	var result strings.Builder
+	for _, description := range targets {
+		result.WriteString(fmt.Sprintf("%s\n", description))
+	}
+	if len(refs) == 0 {
+		result.WriteString("No references found\n")
+		return result.String(), nil
+	}
+
+	result.WriteString(fmt.Sprintf("Found %d references:\n", len(refs)))
+	for i, ref := range refs {
+		if i >= maxResults {
+			result.WriteString(fmt.Sprintf("... %d more references not shown\n", len(refs)-maxResults))
+			break
+		}
+		result.WriteString(fmt.Sprintf("  %s: %s\n", relPosition(ref.pos), ref.line))
+	}
+
+	return result.String(), nil
+}
+
+// sourceLine returns the trimmed text of a 1-based line in a file
+func sourceLine(filename string, line int) string {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(content), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[line-1])
+}
+
+func (t *FindReferencesTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"symbol": {
+				Type:        "string",
+				Description: "Symbol name, optionally qualified: Name, Type.Method, Type.Field or pkg.Name",
+			},
+			"path": {
+				Type:        "string",
+				Description: "Module directory to search (default: current directory)",
+			},
+			"max_results": {
+				Type:        "number",
+				Description: "Maximum number of references to return (default: 200)",
+			},
+		},
+		Required: []string{"symbol"},
+	}
+}
+
+// ListSymbolsTool lists the package-level declarations of Go packages
+type ListSymbolsTool struct{}
+
+func (t *ListSymbolsTool) Name() string {
+	return "list_symbols"
+}
+
+func (t *ListSymbolsTool) ReadOnly() bool {
+	return true
+}
+
+func (t *ListSymbolsTool) Description() string {
+	return "List package-level Go symbols (types, functions, methods, consts, vars) with file:line and signature"
+}
+
+func (t *ListSymbolsTool) Execute(args map[string]interface{}) (string, error) {
+	root := stringArg(args, "path", ".")
+	query := strings.ToLower(stringArg(args, "query", ""))
+	exportedOnly := boolArg(args, "exported_only")
+
+	pkgs, err := loadGoPackages(root)
+	if err != nil {
+		return "", err
+	}
+
+	type symbol struct {
+		pos         token.Position
+		pkg         string
+		description string
+	}
+	var symbols []symbol
+	seen := make(map[string]bool)
+
+	addSymbol := func(pkg *packages.Package, obj types.Object) {
+		if exportedOnly && !obj.Exported() {
+			return
+		}
+		if query != "" && !strings.Contains(strings.ToLower(obj.Name()), query) {
+			return
+		}
+		pos := pkg.Fset.Position(obj.Pos())
+		if seen[pos.String()] {
+			return
+		}
+		seen[pos.String()] = true
+		symbols = append(symbols, symbol{pos: pos, pkg: pkg.PkgPath, description: describeGoObject(obj)})
+	}
+
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj := scope.Lookup(name)
+			addSymbol(pkg, obj)
+
+			if tn, ok := obj.(*types.TypeName); ok {
+				if named, ok := tn.Type().(*types.Named); ok {
+					for i := 0; i < named.NumMethods(); i++ {
+						addSymbol(pkg, named.Method(i))
+					}
+				}
+			}
+		}
+	}
+
+	if len(symbols) == 0 {
+		return "No symbols found", nil
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		if symbols[i].pos.Filename != symbols[j].pos.Filename {
+			return symbols[i].pos.Filename < symbols[j].pos.Filename
+		}
+		return symbols[i].pos.Line < symbols[j].pos.Line
+	})
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d symbols:\n", len(symbols)))
+	currentPkg := ""
+	for i, sym := range symbols {
+		if i >= maxSymbolResults {
+			result.WriteString(fmt.Sprintf("... %d more symbols not shown (use query or a narrower path)\n", len(symbols)-maxSymbolResults))
+			break
+		}
+		if sym.pkg != currentPkg {
+			currentPkg = sym.pkg
+			result.WriteString(fmt.Sprintf("package %s\n", sym.pkg))
+		}
+		result.WriteString(fmt.Sprintf("  %s: %s\n", relPosition(sym.pos), sym.description))
+	}
+
+	return result.String(), nil
+}
+
+func (t *ListSymbolsTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"path": {
+				Type:        "string",
+				Description: "Module directory to list (default: current directory)",
+			},
+			"query": {
+				Type:        "string",
+				Description: "Only list symbols whose name contains this text (case-insensitive, optional)",
+			},
+			"exported_only": {
+				Type:        "boolean",
+				Description: "Only list exported symbols (default: false)",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+// OutlineFileTool shows the declarations in a single Go file
+type OutlineFileTool struct{}
+
+func (t *OutlineFileTool) Name() string {
+	return "outline_file"
+}
+
+func (t *OutlineFileTool) ReadOnly() bool {
+	return true
+}
+
+func (t *OutlineFileTool) Description() string {
+	return "Show an outline of a Go file: package, imports, and each declaration with its line number and signature"
+}
+
+func (t *OutlineFileTool) Execute(args map[string]interface{}) (string, error) {
+	path, ok := args["path"].(string)
+	if !ok {
+		return "", fmt.Errorf("path parameter is required and must be a string")
+	}
+	return GoFileOutline(path)
+}
+
+func (t *OutlineFileTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"path": {
+				Type:        "string",
+				Description: "Path to the Go file to outline",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+// GoFileOutline parses a single Go file (without type checking, so it is cheap)
+// and lists its declarations with line numbers and signatures
+func GoFileOutline(path string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil && file == nil {
+		return "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("package %s", file.Name.Name))
+	if len(file.Imports) > 0 {
+		result.WriteString(fmt.Sprintf(" (%d imports)", len(file.Imports)))
+	}
+	result.WriteString("\n")
+
+	line := func(pos token.Pos) int {
+		return fset.Position(pos).Line
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sig := *d
+			sig.Body = nil
+			sig.Doc = nil
+			result.WriteString(fmt.Sprintf("  %d: %s\n", line(d.Pos()), nodeString(fset, &sig)))
+
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					kind := "type"
+					switch s.Type.(type) {
+					case *ast.StructType:
+						kind = "struct"
+					case *ast.InterfaceType:
+						kind = "interface"
+					}
+					if kind == "type" {
+						result.WriteString(fmt.Sprintf("  %d: type %s %s\n", line(s.Pos()), s.Name.Name, truncateOutline(nodeString(fset, s.Type))))
+					} else {
+						result.WriteString(fmt.Sprintf("  %d: type %s %s\n", line(s.Pos()), s.Name.Name, kind))
+					}
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						entry := fmt.Sprintf("%s %s", d.Tok, name.Name)
+						if s.Type != nil {
+							entry += " " + nodeString(fset, s.Type)
+						}
+						result.WriteString(fmt.Sprintf("  %d: %s\n", line(name.Pos()), entry))
+					}
+				}
+			}
+		}
+	}
+
+	if err != nil {
+		result.WriteString(fmt.Sprintf("(file has syntax errors: %v)\n", err))
+	}
+
+	return result.String(), nil
+}
+
+func nodeString(fset *token.FileSet, node interface{}) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+func truncateOutline(s string) string {
+	if len(s) > 80 {
+		return s[:77] + "..."
+	}
+	return s
+}
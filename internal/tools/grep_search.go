@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/ttli3/go-coding-agent/internal/walker"
+)
+
+const (
+	// defaultGrepMaxResults caps how many matches are returned to the model
+	defaultGrepMaxResults = 200
+	// maxGrepFileSize skips files too large to be source code
+	maxGrepFileSize = 5 * 1024 * 1024
+	// maxGrepWorkers bounds how many files are scanned concurrently
+	maxGrepWorkers = 8
+)
+
+// GrepSearchTool searches for patterns across multiple files
+type GrepSearchTool struct{}
+
+// grepMatch is a matching line plus the context lines around it
+type grepMatch struct {
+	line   int
+	before []string
+	text   string
+	after  []string
+}
+
+// grepFileResult holds all matches found in one file
+type grepFileResult struct {
+	path    string
+	matches []grepMatch
+}
+
+func (t *GrepSearchTool) Name() string {
+	return "grep_search"
+}
+
+func (t *GrepSearchTool) ReadOnly() bool {
+	return true
+}
+
+func (t *GrepSearchTool) Description() string {
+	return "Search for patterns across multiple files in a directory, skipping .gitignore'd files and binaries"
+}
+
+func (t *GrepSearchTool) Execute(args map[string]interface{}) (string, error) {
+	path, ok := args["path"].(string)
+	if !ok {
+		return "", fmt.Errorf("path parameter is required and must be a string")
+	}
+
+	pattern, ok := args["pattern"].(string)
+	if !ok {
+		return "", fmt.Errorf("pattern parameter is required and must be a string")
+	}
+
+	filePattern := stringArg(args, "file_pattern", "*")
+	useRegex := boolArg(args, "regex")
+	before := intArg(args, "before_context", 0)
+	after := intArg(args, "after_context", 0)
+	if ctxLines := intArg(args, "context", 0); ctxLines > 0 {
+		before, after = ctxLines, ctxLines
+	}
+	maxResults := intArg(args, "max_results", defaultGrepMaxResults)
+	if maxResults <= 0 {
+		maxResults = defaultGrepMaxResults
+	}
+
+	var regex *regexp.Regexp
+	if useRegex {
+		var err error
+		regex, err = regexp.Compile(pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid regex pattern: %w", err)
+		}
+	}
+
+	matchLine := func(line string) bool {
+		if useRegex {
+			return regex.MatchString(line)
+		}
+		return strings.Contains(line, pattern)
+	}
+
+	// Collect candidate files first so results keep a stable, walk-ordered layout
+	var files []string
+	opts := walker.Options{
+		IncludeIgnored: boolArg(args, "include_ignored"),
+		IncludeHidden:  boolArg(args, "include_hidden"),
+	}
+	err := walker.Walk(path, opts, func(filePath, rel string, d fs.DirEntry) error {
+		if matched, err := filepath.Match(filePattern, d.Name()); err != nil || !matched {
+			return nil
+		}
+		files = append(files, filePath)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search files: %w", err)
+	}
+
+	results := make([]*grepFileResult, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	workers := runtime.NumCPU()
+	if workers > maxGrepWorkers {
+		workers = maxGrepWorkers
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = grepFile(files[i], matchLine, before, after)
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	totalMatches, matchedFiles := 0, 0
+	for _, fileResult := range results {
+		if fileResult != nil && len(fileResult.matches) > 0 {
+			totalMatches += len(fileResult.matches)
+			matchedFiles++
+		}
+	}
+
+	if totalMatches == 0 {
+		return fmt.Sprintf("No matches found for pattern '%s' in %s", pattern, path), nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d matches for '%s' in %d files:\n", totalMatches, pattern, matchedFiles))
+
+	shown := 0
+	withContext := before > 0 || after > 0
+	for _, fileResult := range results {
+		if fileResult == nil {
+			continue
+		}
+		for _, match := range fileResult.matches {
+			if shown >= maxResults {
+				break
+			}
+			shown++
+
+			if withContext {
+				if shown > 1 {
+					result.WriteString("  --\n")
+				}
+				for j, line := range match.before {
+					result.WriteString(fmt.Sprintf("  %s-%d- %s\n", fileResult.path, match.line-len(match.before)+j, line))
+				}
+			}
+			result.WriteString(fmt.Sprintf("  %s:%d: %s\n", fileResult.path, match.line, match.text))
+			for j, line := range match.after {
+				result.WriteString(fmt.Sprintf("  %s-%d- %s\n", fileResult.path, match.line+j+1, line))
+			}
+		}
+	}
+
+	if totalMatches > shown {
+		result.WriteString(fmt.Sprintf("... %d more matches not shown (narrow the pattern, path or file_pattern, or raise max_results)\n", totalMatches-shown))
+	}
+
+	return result.String(), nil
+}
+
+// grepFile scans a single file, returning nil for unreadable, oversized or binary files
+func grepFile(path string, matchLine func(string) bool, before, after int) *grepFileResult {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxGrepFileSize {
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil || walker.IsBinary(content) {
+		return nil
+	}
+
+	lines := strings.Split(string(content), "\n")
+	fileResult := &grepFileResult{path: path}
+
+	for i, line := range lines {
+		if !matchLine(line) {
+			continue
+		}
+
+		match := grepMatch{line: i + 1, text: strings.TrimSpace(line)}
+		for j := max(0, i-before); j < i; j++ {
+			match.before = append(match.before, strings.TrimSpace(lines[j]))
+		}
+		for j := i + 1; j <= i+after && j < len(lines); j++ {
+			match.after = append(match.after, strings.TrimSpace(lines[j]))
+		}
+		fileResult.matches = append(fileResult.matches, match)
+	}
+
+	return fileResult
+}
+
+func (t *GrepSearchTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"path": {
+				Type:        "string",
+				Description: "Directory path to search in",
+			},
+			"pattern": {
+				Type:        "string",
+				Description: "Pattern to search for",
+			},
+			"file_pattern": {
+				Type:        "string",
+				Description: "File name pattern to limit search (default: *)",
+			},
+			"regex": {
+				Type:        "boolean",
+				Description: "Whether to treat pattern as a regular expression (default: false)",
+			},
+			"before_context": {
+				Type:        "number",
+				Description: "Lines of context to show before each match, like grep -B (default: 0)",
+			},
+			"after_context": {
+				Type:        "number",
+				Description: "Lines of context to show after each match, like grep -A (default: 0)",
+			},
+			"context": {
+				Type:        "number",
+				Description: "Lines of context before and after each match, like grep -C (overrides before/after)",
+			},
+			"max_results": {
+				Type:        "number",
+				Description: "Maximum number of matches to return (default: 200)",
+			},
+			"include_ignored": {
+				Type:        "boolean",
+				Description: "Also search .gitignore'd files and node_modules/vendor (default: false)",
+			},
+			"include_hidden": {
+				Type:        "boolean",
+				Description: "Also search hidden files and directories (default: false)",
+			},
+		},
+		Required: []string{"path", "pattern"},
+	}
+}
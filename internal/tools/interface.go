@@ -13,6 +13,18 @@ type Tool interface {
 	Schema() ToolSchema
 }
 
+// ReadOnlyTool is implemented by tools that only inspect the project and
+// never change files or run arbitrary commands
+type ReadOnlyTool interface {
+	ReadOnly() bool
+}
+
+// IsReadOnly reports whether a tool is known not to modify anything
+func IsReadOnly(tool Tool) bool {
+	ro, ok := tool.(ReadOnlyTool)
+	return ok && ro.ReadOnly()
+}
+
 // ToolSchema defines the JSON schema for a tool's parameters
 type ToolSchema struct {
 	Type       string                        `json:"type"`
@@ -20,11 +32,15 @@ type ToolSchema struct {
 	Required   []string                      `json:"required"`
 }
 
-// PropertyDefinition defines a parameter property
+// PropertyDefinition defines a parameter property. Items describes the
+// elements of an array, and Properties and Required the fields of an object.
 type PropertyDefinition struct {
-	Type        string   `json:"type"`
-	Description string   `json:"description"`
-	Enum        []string `json:"enum,omitempty"`
+	Type        string                        `json:"type"`
+	Description string                        `json:"description,omitempty"`
+	Enum        []string                      `json:"enum,omitempty"`
+	Items       *PropertyDefinition           `json:"items,omitempty"`
+	Properties  map[string]PropertyDefinition `json:"properties,omitempty"`
+	Required    []string                      `json:"required,omitempty"`
 }
 
 // ToolCall represents a tool call from the AI
@@ -43,7 +59,9 @@ type ToolResult struct {
 
 // Registry manages all available tools
 type Registry struct {
-	tools map[string]Tool
+	tools    map[string]Tool
+	readOnly bool   // only read-only tools are listed and executed
+	root     string // path arguments must resolve inside it, when set
 }
 
 // NewRegistry creates a new tool registry
@@ -58,21 +76,46 @@ func (r *Registry) Register(tool Tool) {
 	r.tools[tool.Name()] = tool
 }
 
-// Get retrieves a tool by name
+// SetReadOnly restricts the registry to read-only tools, or lifts the restriction
+func (r *Registry) SetReadOnly(readOnly bool) {
+	r.readOnly = readOnly
+}
+
+// ReadOnly reports whether the registry is restricted to read-only tools
+func (r *Registry) ReadOnly() bool {
+	return r.readOnly
+}
+
+// Get retrieves a tool by name, even if it is currently unavailable
 func (r *Registry) Get(name string) (Tool, bool) {
 	tool, exists := r.tools[name]
 	return tool, exists
 }
 
-// List returns all registered tools
+// List returns all available tools
 func (r *Registry) List() []Tool {
 	tools := make([]Tool, 0, len(r.tools))
 	for _, tool := range r.tools {
+		if r.readOnly && !IsReadOnly(tool) {
+			continue
+		}
 		tools = append(tools, tool)
 	}
 	return tools
 }
 
+// Subset returns a new registry holding the registered tools accepted by keep
+func (r *Registry) Subset(keep func(Tool) bool) *Registry {
+	subset := NewRegistry()
+	subset.root = r.root
+	for _, tool := range r.tools {
+		if keep(tool) {
+			subset.Register(tool)
+		}
+	}
+	return subset
+}
+
 // Execute runs a tool with the given arguments
 func (r *Registry) Execute(name string, args map[string]interface{}) *ToolResult {
 	tool, exists := r.tools[name]
@@ -83,6 +126,22 @@ func (r *Registry) Execute(name string, args map[string]interface{}) *ToolResult
 			Success: false,
 		}
 	}
+	if r.readOnly && !IsReadOnly(tool) {
+		return &ToolResult{
+			Name:    name,
+			Error:   fmt.Sprintf("tool '%s' is not available: only read-only tools are allowed in plan mode", name),
+			Success: false,
+		}
+	}
+	if r.root != "" {
+		if err := r.checkPaths(args); err != nil {
+			return &ToolResult{
+				Name:    name,
+				Error:   err.Error(),
+				Success: false,
+			}
+		}
+	}
 
 	result, err := tool.Execute(args)
 	if err != nil {
@@ -123,7 +182,8 @@ func ParseToolCalls(content string) ([]ToolCall, error) {
 	return []ToolCall{}, nil
 }
 
-// GetDefaultRegistry returns a registry with all default tools registered
+// GetDefaultRegistry returns a registry with all default tools and the
+// plugins from the user and project plugin directories registered
 func GetDefaultRegistry() *Registry {
 	registry := NewRegistry()
 
@@ -139,6 +199,14 @@ func GetDefaultRegistry() *Registry {
 	registry.Register(&FindFilesTool{})
 	registry.Register(&GrepSearchTool{})
 	registry.Register(&DiffTool{})
+	registry.Register(&GitStatusTool{})
+	registry.Register(&GitDiffTool{})
+	registry.Register(&GitLogTool{})
+	registry.Register(&GitBlameTool{})
+	registry.Register(&GitShowTool{})
+
+	// Executables from the plugin directories
+	registerPlugins(registry)
 
 	return registry
 }
@@ -0,0 +1,101 @@
+package tools
+
+import "encoding/json"
+
+// SchemaFromJSON maps a JSON schema for a tool's arguments onto ToolSchema,
+// for tools described by other programs. Invalid schemas take no arguments.
+func SchemaFromJSON(data []byte) ToolSchema {
+	schema := ToolSchema{Type: "object", Properties: map[string]PropertyDefinition{}}
+	var raw map[string]interface{}
+	if json.Unmarshal(data, &raw) != nil {
+		return schema
+	}
+	property := convertProperty(raw)
+	if property.Properties != nil {
+		schema.Properties = property.Properties
+	}
+	schema.Required = property.Required
+	return schema
+}
+
+// convertProperty maps a JSON schema onto the subset the tool interface
+// describes: a union takes its first non-null type, and non-string enums
+// are dropped
+func convertProperty(raw map[string]interface{}) PropertyDefinition {
+	var property PropertyDefinition
+	property.Description, _ = raw["description"].(string)
+	property.Type = schemaType(raw)
+
+	if values, ok := raw["enum"].([]interface{}); ok {
+		for _, value := range values {
+			if s, ok := value.(string); ok {
+				property.Enum = append(property.Enum, s)
+			}
+		}
+		if len(property.Enum) != len(values) {
+			property.Enum = nil
+		}
+	}
+	if items, ok := raw["items"].(map[string]interface{}); ok {
+		converted := convertProperty(items)
+		property.Items = &converted
+	}
+	if properties, ok := raw["properties"].(map[string]interface{}); ok {
+		property.Properties = make(map[string]PropertyDefinition, len(properties))
+		for name, value := range properties {
+			if sub, ok := value.(map[string]interface{}); ok {
+				property.Properties[name] = convertProperty(sub)
+			}
+		}
+	}
+	if required, ok := raw["required"].([]interface{}); ok {
+		for _, name := range required {
+			if s, ok := name.(string); ok {
+				property.Required = append(property.Required, s)
+			}
+		}
+	}
+	if property.Type == "array" && property.Items == nil {
+		property.Items = &PropertyDefinition{Type: "string"}
+	}
+	return property
+}
+
+// schemaType returns the first non-null type of a schema, looking into
+// anyOf and oneOf; untyped schemas are treated as objects when they have
+// properties and as strings otherwise
+func schemaType(raw map[string]interface{}) string {
+	if t := explicitType(raw); t != "" {
+		return t
+	}
+	if _, ok := raw["properties"]; ok {
+		return "object"
+	}
+	return "string"
+}
+
+func explicitType(raw map[string]interface{}) string {
+	switch value := raw["type"].(type) {
+	case string:
+		if value != "null" {
+			return value
+		}
+	case []interface{}:
+		for _, t := range value {
+			if s, ok := t.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	for _, key := range []string{"anyOf", "oneOf"} {
+		branches, _ := raw[key].([]interface{})
+		for _, branch := range branches {
+			if sub, ok := branch.(map[string]interface{}); ok {
+				if t := explicitType(sub); t != "" {
+					return t
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,307 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// PluginDir is where plugin executables live, under the home directory and
+// under the project (found from the working directory upwards)
+const PluginDir = ".agent_go/tools"
+
+// PluginTrust decides which plugins may run. Plugins in the user directory
+// always load; a project's plugins load only if the project is trusted.
+type PluginTrust struct {
+	TrustedDirs []string // project directories, "~/" is expanded
+	ReadOnly    []string // plugins that run without approval
+}
+
+var pluginTrust = struct {
+	sync.Mutex
+	PluginTrust
+}{}
+
+// SetPluginTrust sets the trust applied by registries created afterwards
+func SetPluginTrust(trust PluginTrust) {
+	pluginTrust.Lock()
+	pluginTrust.PluginTrust = trust
+	pluginTrust.Unlock()
+}
+
+// schemaTimeout bounds a plugin's --schema call
+const schemaTimeout = 10 * time.Second
+
+// defaultPluginTimeout applies to plugins whose schema sets no timeout
+const defaultPluginTimeout = 60 * time.Second
+
+var validPluginName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// pluginSchema is what a plugin prints for --schema
+type pluginSchema struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"` // JSON schema of the arguments
+	Timeout     int             `json:"timeout"`    // seconds
+}
+
+// pluginOutput is what a plugin prints after a call
+type pluginOutput struct {
+	Result string `json:"result"`
+	Error  string `json:"error"`
+}
+
+// PluginTool runs an executable that describes itself with --schema. Each
+// call passes the arguments as JSON on stdin and reads a JSON object with
+// result or error from stdout.
+type PluginTool struct {
+	path     string
+	schema   pluginSchema
+	readOnly bool
+}
+
+func (t *PluginTool) Name() string {
+	return t.schema.Name
+}
+
+func (t *PluginTool) Description() string {
+	return t.schema.Description
+}
+
+// ReadOnly reports whether the user listed the plugin as read-only; what a
+// plugin says about itself isn't trusted
+func (t *PluginTool) ReadOnly() bool {
+	return t.readOnly
+}
+
+// Path returns the plugin's executable
+func (t *PluginTool) Path() string {
+	return t.path
+}
+
+func (t *PluginTool) Schema() ToolSchema {
+	return SchemaFromJSON(t.schema.Parameters)
+}
+
+func (t *PluginTool) Execute(args map[string]interface{}) (string, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	input, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	timeout := defaultPluginTimeout
+	if t.schema.Timeout > 0 {
+		timeout = time.Duration(t.schema.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, t.path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(), "AGENT_TOOL_NAME="+t.schema.Name)
+	cmd.WaitDelay = time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("%s timed out after %s", t.schema.Name, timeout)
+	}
+
+	var output pluginOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		if runErr != nil {
+			return "", fmt.Errorf("%s failed: %v\n%s", t.schema.Name, runErr, strings.TrimSpace(stderr.String()))
+		}
+		return "", fmt.Errorf("%s printed invalid JSON: %v\n%s", t.schema.Name, err, strings.TrimSpace(stdout.String()))
+	}
+	if output.Error != "" {
+		return "", fmt.Errorf("%s", output.Error)
+	}
+	if runErr != nil {
+		return "", fmt.Errorf("%s failed: %v\n%s", t.schema.Name, runErr, strings.TrimSpace(stderr.String()))
+	}
+	return output.Result, nil
+}
+
+// pluginCache keeps the schemas of plugins by path, so registries created
+// later don't run --schema again unless the executable changed
+var pluginCache = struct {
+	sync.Mutex
+	entries map[string]pluginCacheEntry
+}{entries: make(map[string]pluginCacheEntry)}
+
+type pluginCacheEntry struct {
+	modTime time.Time
+	schema  pluginSchema
+	err     error
+}
+
+// registerPlugins adds the plugins from the user and trusted project
+// directories. Built-in tools keep their names, and project plugins replace
+// user plugins of the same name. Plugins that can't describe themselves are
+// reported and skipped.
+func registerPlugins(registry *Registry) {
+	pluginTrust.Lock()
+	trust := pluginTrust.PluginTrust
+	pluginTrust.Unlock()
+
+	for _, dir := range pluginDirs() {
+		paths := pluginExecutables(dir.path)
+		if dir.project != "" && len(paths) > 0 && !trust.trusts(dir.project) {
+			color.New(color.FgYellow).Printf("[PLUGIN] Not loading the plugins in %s; add %s to plugins.trusted_dirs in ~/.agent_go.yaml to trust them\n", dir.path, dir.project)
+			continue
+		}
+		for _, path := range paths {
+			schema, err := loadPluginSchema(path)
+			if err != nil {
+				color.New(color.FgYellow).Printf("[PLUGIN] Skipping %s: %v\n", path, err)
+				continue
+			}
+			if existing, ok := registry.Get(schema.Name); ok {
+				if _, isPlugin := existing.(*PluginTool); !isPlugin {
+					color.New(color.FgYellow).Printf("[PLUGIN] Skipping %s: %s is a built-in tool\n", path, schema.Name)
+					continue
+				}
+			}
+			registry.Register(&PluginTool{path: path, schema: schema, readOnly: slices.Contains(trust.ReadOnly, schema.Name)})
+		}
+	}
+}
+
+// trusts reports whether the user trusts the project's plugins
+func (t PluginTrust) trusts(project string) bool {
+	home, _ := os.UserHomeDir()
+	for _, dir := range t.TrustedDirs {
+		if home != "" && (dir == "~" || strings.HasPrefix(dir, "~/")) {
+			dir = filepath.Join(home, dir[1:])
+		}
+		if abs, err := filepath.Abs(dir); err == nil && abs == project {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginDir is a plugin directory and, for a project's, the project root
+type pluginDir struct {
+	path    string
+	project string
+}
+
+// pluginDirs returns the user directory, then the nearest project directory
+// above the working directory
+func pluginDirs() []pluginDir {
+	var dirs []pluginDir
+	home, _ := os.UserHomeDir()
+	if home != "" {
+		dirs = append(dirs, pluginDir{path: filepath.Join(home, PluginDir)})
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return dirs
+	}
+	for {
+		candidate := filepath.Join(dir, PluginDir)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			if len(dirs) == 0 || candidate != dirs[0].path {
+				dirs = append(dirs, pluginDir{path: candidate, project: dir})
+			}
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir || dir == home {
+			break
+		}
+		dir = parent
+	}
+	return dirs
+}
+
+// pluginExecutables lists the executable files in dir, skipping hidden ones
+func pluginExecutables(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path) // follows symbolic links
+		if err != nil || !info.Mode().IsRegular() || info.Mode()&0111 == 0 {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// loadPluginSchema runs the plugin with --schema, or reuses the answer from
+// an earlier run of the same executable
+func loadPluginSchema(path string) (pluginSchema, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return pluginSchema{}, err
+	}
+	pluginCache.Lock()
+	entry, ok := pluginCache.entries[path]
+	pluginCache.Unlock()
+	if ok && entry.modTime.Equal(info.ModTime()) {
+		return entry.schema, entry.err
+	}
+
+	schema, err := describePlugin(path)
+	pluginCache.Lock()
+	pluginCache.entries[path] = pluginCacheEntry{modTime: info.ModTime(), schema: schema, err: err}
+	pluginCache.Unlock()
+	return schema, err
+}
+
+func describePlugin(path string) (pluginSchema, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), schemaTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, "--schema")
+	cmd.WaitDelay = time.Second
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return pluginSchema{}, fmt.Errorf("--schema timed out after %s", schemaTimeout)
+	}
+	if err != nil {
+		return pluginSchema{}, fmt.Errorf("--schema failed: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var schema pluginSchema
+	if err := json.Unmarshal(output, &schema); err != nil {
+		return pluginSchema{}, fmt.Errorf("--schema printed invalid JSON: %w", err)
+	}
+	if schema.Name == "" {
+		schema.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if !validPluginName.MatchString(schema.Name) {
+		return pluginSchema{}, fmt.Errorf("invalid tool name %q (use letters, digits, _ and -)", schema.Name)
+	}
+	if schema.Description == "" {
+		return pluginSchema{}, fmt.Errorf("--schema has no description")
+	}
+	return schema, nil
+}
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ttli3/go-coding-agent/internal/project"
+	"github.com/ttli3/go-coding-agent/internal/testrun"
+)
+
+// LayoutProvider exposes the detected project layout to tools
+type LayoutProvider interface {
+	ProjectLayout() *project.Layout
+}
+
+// RunTestsTool runs the project's tests and returns a parsed summary
+type RunTestsTool struct {
+	layouts LayoutProvider
+}
+
+// NewRunTestsTool creates a test runner for the provider's project
+func NewRunTestsTool(layouts LayoutProvider) *RunTestsTool {
+	return &RunTestsTool{layouts: layouts}
+}
+
+func (t *RunTestsTool) Name() string {
+	return "run_tests"
+}
+
+func (t *RunTestsTool) Description() string {
+	return "Run the tests of the project (or the sub-project, package or directory containing path) and return a summary: counts of passed, failed and skipped tests, and each failure with its output and file:line. Go projects use go test -json; other projects run their detected test command. Use this instead of run_command to run tests."
+}
+
+func (t *RunTestsTool) Execute(args map[string]interface{}) (string, error) {
+	opts := testrun.Options{}
+	if path, ok := args["path"].(string); ok {
+		opts.Path = path
+	}
+	if run, ok := args["run"].(string); ok {
+		opts.Run = run
+	}
+	if timeout, ok := args["timeout"].(float64); ok && timeout > 0 {
+		opts.Timeout = time.Duration(timeout) * time.Second
+	}
+
+	report, err := testrun.Run(t.layouts.ProjectLayout(), opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to run tests: %w", err)
+	}
+	return report.Summary(), nil
+}
+
+func (t *RunTestsTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"path": {
+				Type:        "string",
+				Description: "File or directory to test: a Go file tests its package, a directory everything below it (default: the project root)",
+			},
+			"run": {
+				Type:        "string",
+				Description: "Only run tests whose names match this regular expression (Go -run syntax, e.g. '^TestParse$')",
+			},
+			"timeout": {
+				Type:        "number",
+				Description: "Timeout in seconds (default: 300)",
+			},
+		},
+		Required: []string{},
+	}
+}
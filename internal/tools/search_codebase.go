@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ttli3/go-coding-agent/internal/codeindex"
+)
+
+// defaultSearchResults is how many ranked chunks search_codebase returns
+const defaultSearchResults = 10
+
+// SearchCodebaseTool answers natural-language-ish queries from a BM25 index
+// of the project kept under the project's agent directory
+type SearchCodebaseTool struct {
+	root      string
+	indexPath string
+
+	mu    sync.Mutex
+	index *codeindex.Index
+}
+
+// NewSearchCodebaseTool creates a search tool for the project at root whose
+// index is stored at indexPath
+func NewSearchCodebaseTool(root, indexPath string) *SearchCodebaseTool {
+	return &SearchCodebaseTool{root: root, indexPath: indexPath}
+}
+
+// Refresh loads the index if needed and re-indexes files that changed since
+// it was last saved, writing it back when anything was updated
+func (t *SearchCodebaseTool) Refresh() (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.index == nil {
+		t.index = codeindex.Load(t.root, t.indexPath)
+	}
+
+	updated, err := t.index.Refresh()
+	if err != nil {
+		return 0, err
+	}
+	if updated > 0 {
+		if err := t.index.Save(t.indexPath); err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+func (t *SearchCodebaseTool) Name() string {
+	return "search_codebase"
+}
+
+func (t *SearchCodebaseTool) ReadOnly() bool {
+	return true
+}
+
+func (t *SearchCodebaseTool) Description() string {
+	return "Search the whole project by meaning-bearing words (e.g. 'where are sessions saved') and get ranked file:line snippets. Identifiers are split on camelCase and snake_case"
+}
+
+func (t *SearchCodebaseTool) Execute(args map[string]interface{}) (string, error) {
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("query parameter is required and must be a string")
+	}
+
+	maxResults := intArg(args, "max_results", defaultSearchResults)
+	if maxResults <= 0 {
+		maxResults = defaultSearchResults
+	}
+	path := stringArg(args, "path", "")
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(t.root, path); err == nil {
+			path = rel
+		}
+	}
+
+	// Pick up edits made since the session started; unchanged files are skipped
+	if _, err := t.Refresh(); err != nil {
+		return "", fmt.Errorf("failed to update search index: %w", err)
+	}
+
+	t.mu.Lock()
+	results := t.index.Search(query, maxResults, path)
+	t.mu.Unlock()
+
+	if len(results) == 0 {
+		return fmt.Sprintf("No results for '%s'. Try different words, an identifier name, or grep_search for exact text", query), nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Top %d results for '%s':\n", len(results), query))
+	for i, r := range results {
+		result.WriteString(fmt.Sprintf("%d. %s:%d-%d (score %.2f)\n", i+1, r.Doc.File, r.Doc.StartLine, r.Doc.EndLine, r.Score))
+		for _, line := range r.Lines {
+			result.WriteString(fmt.Sprintf("   %d: %s\n", line.Number, line.Text))
+		}
+	}
+
+	return result.String(), nil
+}
+
+func (t *SearchCodebaseTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"query": {
+				Type:        "string",
+				Description: "Words describing what to find, e.g. 'retry http request on timeout' or 'parseConfig'",
+			},
+			"max_results": {
+				Type:        "number",
+				Description: "Maximum number of ranked snippets to return (default: 10)",
+			},
+			"path": {
+				Type:        "string",
+				Description: "Only search files under this directory, relative to the project root (optional)",
+			},
+		},
+		Required: []string{"query"},
+	}
+}
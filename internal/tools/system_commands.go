@@ -111,6 +111,10 @@ func (t *GetWorkingDirectoryTool) Name() string {
 	return "get_working_directory"
 }
 
+func (t *GetWorkingDirectoryTool) ReadOnly() bool {
+	return true
+}
+
 func (t *GetWorkingDirectoryTool) Description() string {
 	return "Get the current working directory"
 }
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/ttli3/go-coding-agent/internal/context"
+)
+
+// TodoStore holds the checklist the model keeps for multi-step work
+type TodoStore interface {
+	UpdateTodos(items []context.TodoItem) (string, error)
+	TodoList() string
+}
+
+// TodoWriteTool replaces the model's checklist
+type TodoWriteTool struct {
+	store TodoStore
+}
+
+// NewTodoWriteTool creates a todo_write tool backed by store
+func NewTodoWriteTool(store TodoStore) *TodoWriteTool {
+	return &TodoWriteTool{store: store}
+}
+
+func (t *TodoWriteTool) Name() string {
+	return "todo_write"
+}
+
+func (t *TodoWriteTool) Description() string {
+	return "Create or update your checklist for multi-step work. Send the complete list every time; " +
+		"mark exactly one item in_progress while working on it and mark it completed as soon as it is done. " +
+		"Use it for tasks with three or more steps, not for trivial ones"
+}
+
+func (t *TodoWriteTool) Execute(args map[string]interface{}) (string, error) {
+	raw, ok := args["todos"].([]interface{})
+	if !ok {
+		return "", fmt.Errorf("todos must be an array of {content, status} objects")
+	}
+
+	items := make([]context.TodoItem, 0, len(raw))
+	for i, entry := range raw {
+		fields, ok := entry.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("todo %d must be an object with content and status", i+1)
+		}
+		items = append(items, context.TodoItem{
+			ID:      stringArg(fields, "id", ""),
+			Content: stringArg(fields, "content", ""),
+			Status:  stringArg(fields, "status", ""),
+		})
+	}
+
+	return t.store.UpdateTodos(items)
+}
+
+func (t *TodoWriteTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"todos": {
+				Type:        "array",
+				Description: "The full checklist, in order",
+				Items: &PropertyDefinition{
+					Type: "object",
+					Properties: map[string]PropertyDefinition{
+						"id": {
+							Type:        "string",
+							Description: "Stable ID for the item (defaults to its position)",
+						},
+						"content": {
+							Type:        "string",
+							Description: "What needs to be done",
+						},
+						"status": {
+							Type:        "string",
+							Description: "Item status",
+							Enum:        []string{context.TodoPending, context.TodoInProgress, context.TodoCompleted},
+						},
+					},
+					Required: []string{"content", "status"},
+				},
+			},
+		},
+		Required: []string{"todos"},
+	}
+}
+
+// TodoReadTool shows the model's current checklist
+type TodoReadTool struct {
+	store TodoStore
+}
+
+// NewTodoReadTool creates a todo_read tool backed by store
+func NewTodoReadTool(store TodoStore) *TodoReadTool {
+	return &TodoReadTool{store: store}
+}
+
+func (t *TodoReadTool) Name() string {
+	return "todo_read"
+}
+
+func (t *TodoReadTool) ReadOnly() bool {
+	return true
+}
+
+func (t *TodoReadTool) Description() string {
+	return "Show your current checklist with each item's status and the files changed for it"
+}
+
+func (t *TodoReadTool) Execute(args map[string]interface{}) (string, error) {
+	return t.store.TodoList(), nil
+}
+
+func (t *TodoReadTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type:       "object",
+		Properties: map[string]PropertyDefinition{},
+	}
+}
@@ -14,6 +14,17 @@ type CommandPrompt struct {
 	formatter *ResponseFormatter
 }
 
+// nonInteractive stops prompts from reading stdin, for runs without a person
+// at the terminal
+var nonInteractive bool
+
+// SetNonInteractive makes prompts answer themselves: commands are approved,
+// since the caller's permission policy has already decided which tools may
+// run, and choices take their default
+func SetNonInteractive(enabled bool) {
+	nonInteractive = enabled
+}
+
 // NewCommandPrompt creates a new command prompt handler
 func NewCommandPrompt() *CommandPrompt {
 	return &CommandPrompt{
@@ -23,6 +34,10 @@ func NewCommandPrompt() *CommandPrompt {
 
 // ConfirmCommand displays a command and asks for user confirmation
 func (cp *CommandPrompt) ConfirmCommand(command, workingDir string) bool {
+	if nonInteractive {
+		return true
+	}
+
 	// Create a visually distinct command block
 	fmt.Println()
 	color.New(color.FgYellow, color.Bold).Println("COMMAND EXECUTION REQUEST")
@@ -60,6 +75,41 @@ func (cp *CommandPrompt) ConfirmCommand(command, workingDir string) bool {
 	return response == "y" || response == "yes"
 }
 
+// AskChoice shows a question with single-letter choices and returns the chosen
+// option, or defaultChoice if the input is empty or unrecognized
+func (cp *CommandPrompt) AskChoice(question string, choices []string, defaultChoice string) string {
+	if nonInteractive {
+		return defaultChoice
+	}
+
+	fmt.Println()
+	color.New(color.FgYellow, color.Bold).Println(question)
+
+	var labels []string
+	for _, choice := range choices {
+		label := fmt.Sprintf("[%s]%s", choice[:1], choice[1:])
+		if choice == defaultChoice {
+			label = strings.ToUpper(label)
+		}
+		labels = append(labels, label)
+	}
+	color.New(color.FgWhite).Printf("%s: ", strings.Join(labels, " / "))
+
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return defaultChoice
+	}
+
+	response = strings.TrimSpace(strings.ToLower(response))
+	for _, choice := range choices {
+		if response == choice || (response != "" && strings.HasPrefix(choice, response)) {
+			return choice
+		}
+	}
+	return defaultChoice
+}
+
 // isDangerousCommand checks if a command might be dangerous
 func (cp *CommandPrompt) isDangerousCommand(command string) bool {
 	dangerousCommands := []string{
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
@@ -14,6 +15,9 @@ type ToolExecutionDisplay struct {
 	startTime   time.Time
 	totalTools  int
 	completed   int
+
+	mu         sync.Mutex // serializes output while tools run in parallel
+	groupStart time.Time
 }
 
 // NewToolExecutionDisplay creates a new tool execution display
@@ -61,8 +65,10 @@ func (ted *ToolExecutionDisplay) FinishTool(success bool, result string, err err
 	
 	color.New(color.FgHiBlack).Printf(" (%s)\n", formatDuration(duration))
 	
-	// Show result summary (truncated)
-	if success && result != "" {
+	// Show result summary (truncated); checklists are shown in full
+	if success && (ted.currentTool == "todo_write" || ted.currentTool == "todo_read") {
+		ted.displayTodoList(result)
+	} else if success && result != "" {
 		summary := ted.summarizeResult(ted.currentTool, result)
 		if summary != "" {
 			color.New(color.FgHiBlack).Print("│ ")
@@ -76,6 +82,85 @@ func (ted *ToolExecutionDisplay) FinishTool(success bool, result string, err err
 	color.New(color.FgHiBlack).Println("└─────────────────────────────────────────")
 }
 
+// StartParallel displays the start of a group of tools that run concurrently
+func (ted *ToolExecutionDisplay) StartParallel(toolNames []string) {
+	ted.mu.Lock()
+	defer ted.mu.Unlock()
+
+	ted.groupStart = time.Now()
+	first := ted.completed + 1
+	color.New(color.FgHiBlack).Printf("\n┌─ Tools %d-%d/%d in parallel: ", first, first+len(toolNames)-1, ted.totalTools)
+	color.New(color.FgCyan, color.Bold).Print(strings.Join(toolNames, ", "))
+	color.New(color.FgHiBlack).Println(" ─────")
+	color.New(color.FgHiBlack).Print("│ ")
+	color.New(color.FgYellow).Printf("[EXEC] Executing %d tools...\n", len(toolNames))
+}
+
+// FinishParallelTool displays one tool of a parallel group as it completes.
+// It is safe to call from several goroutines.
+func (ted *ToolExecutionDisplay) FinishParallelTool(toolName string, args map[string]interface{}, duration time.Duration, success bool, result string, err error) {
+	ted.mu.Lock()
+	defer ted.mu.Unlock()
+
+	ted.completed++
+	color.New(color.FgHiBlack).Print("│ ")
+	if success {
+		color.New(color.FgGreen).Print("[OK] ")
+	} else {
+		color.New(color.FgRed).Print("[FAIL] ")
+	}
+	color.New(color.FgCyan).Print(toolName)
+	color.New(color.FgHiBlack).Printf(" (%s)", formatDuration(duration))
+
+	var detail string
+	if success {
+		detail = ted.summarizeResult(toolName, result)
+	} else if err != nil {
+		detail = "Error: " + ted.truncateString(err.Error(), 60)
+	}
+	if target := ted.primaryArg(args); target != "" {
+		if detail == "" {
+			detail = target
+		} else {
+			detail = target + " - " + detail
+		}
+	}
+	if detail != "" {
+		color.New(color.FgWhite).Printf(" %s", detail)
+	}
+	fmt.Println()
+}
+
+// Serialize runs fn while no other goroutine is printing to the display, for
+// output and prompts from the tools of a parallel group
+func (ted *ToolExecutionDisplay) Serialize(fn func()) {
+	ted.mu.Lock()
+	defer ted.mu.Unlock()
+
+	fn()
+}
+
+// FinishParallel closes the display of a parallel group
+func (ted *ToolExecutionDisplay) FinishParallel() {
+	ted.mu.Lock()
+	defer ted.mu.Unlock()
+
+	color.New(color.FgHiBlack).Printf("└─ Group finished in %s ───────────────────\n", formatDuration(time.Since(ted.groupStart)))
+}
+
+// primaryArg picks the argument that best identifies a call in a one-line summary
+func (ted *ToolExecutionDisplay) primaryArg(args map[string]interface{}) string {
+	for _, key := range []string{"path", "file_path", "filename", "pattern", "query", "symbol", "command"} {
+		if str, ok := args[key].(string); ok && str != "" {
+			if strings.Contains(key, "path") || key == "filename" {
+				return ted.shortenPath(str)
+			}
+			return fmt.Sprintf("\"%s\"", ted.truncateString(str, 30))
+		}
+	}
+	return ""
+}
+
 // displaySimplifiedArgs shows a simplified view of tool arguments
 func (ted *ToolExecutionDisplay) displaySimplifiedArgs(args map[string]interface{}) {
 	var parts []string
@@ -112,6 +197,23 @@ func (ted *ToolExecutionDisplay) displaySimplifiedArgs(args map[string]interface
 	}
 }
 
+// displayTodoList shows the model's checklist with each item colored by status
+func (ted *ToolExecutionDisplay) displayTodoList(list string) {
+	for _, line := range strings.Split(list, "\n") {
+		color.New(color.FgHiBlack).Print("│ ")
+		switch {
+		case strings.HasPrefix(line, "[x]"):
+			color.New(color.FgGreen).Println(line)
+		case strings.HasPrefix(line, "[>]"):
+			color.New(color.FgYellow, color.Bold).Println(line)
+		case strings.HasPrefix(line, "[ ]"):
+			color.New(color.FgWhite).Println(line)
+		default:
+			color.New(color.FgHiBlack).Println(line)
+		}
+	}
+}
+
 // summarizeResult creates a brief summary of tool results
 func (ted *ToolExecutionDisplay) summarizeResult(toolName, result string) string {
 	switch toolName {
@@ -138,6 +240,9 @@ func (ted *ToolExecutionDisplay) summarizeResult(toolName, result string) string
 			return fmt.Sprintf("Command output: %d lines", lines)
 		}
 		return ted.truncateString(result, 50)
+	case "run_tests":
+		// The first line is the headline, e.g. "FAIL: 40 passed, 2 failed, ..."
+		return ted.truncateString(strings.SplitN(result, "\n", 2)[0], 70)
 	case "search_code", "grep_search":
 		matches := strings.Count(result, "\n")
 		return fmt.Sprintf("Found %d matches", matches)
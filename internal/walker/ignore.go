@@ -0,0 +1,220 @@
+package walker
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreFiles are read from every directory while walking
+var ignoreFiles = []string{".gitignore", ".ignore"}
+
+// ignoreRule is a single compiled line from an ignore file
+type ignoreRule struct {
+	base    string // directory containing the ignore file, relative to the repository root
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// matcher evaluates gitignore-style rules collected while walking a tree
+type matcher struct {
+	rules map[string][]ignoreRule // keyed by directory relative to the repository root
+}
+
+func newMatcher() *matcher {
+	return &matcher{rules: make(map[string][]ignoreRule)}
+}
+
+// loadDir reads the ignore files in dir (relative path rel) into the matcher
+func (m *matcher) loadDir(dir, rel string) {
+	for _, name := range ignoreFiles {
+		m.loadFile(filepath.Join(dir, name), rel)
+	}
+}
+
+// loadParents reads .git/info/exclude and the ignore files of every directory
+// from the repository root down to dir, excluding dir itself. It returns dir
+// relative to the repository root, or "." when dir is the root or isn't in
+// a repository.
+func (m *matcher) loadParents(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "."
+	}
+	top := abs
+	for {
+		if _, err := os.Stat(filepath.Join(top, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(top)
+		if parent == top {
+			return "."
+		}
+		top = parent
+	}
+
+	m.loadFile(filepath.Join(top, ".git", "info", "exclude"), ".")
+	rel, err := filepath.Rel(top, abs)
+	if err != nil || rel == "." {
+		return "."
+	}
+	rel = filepath.ToSlash(rel)
+	m.loadDir(top, ".")
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		parent := strings.Join(parts[:i], "/")
+		m.loadDir(filepath.Join(top, filepath.FromSlash(parent)), parent)
+	}
+	return rel
+}
+
+// joinRel joins slash-separated relative paths, either of which may be "."
+func joinRel(base, rel string) string {
+	if base == "." {
+		return rel
+	}
+	if rel == "." {
+		return base
+	}
+	return base + "/" + rel
+}
+
+// loadFile parses one ignore file whose patterns are relative to rel
+func (m *matcher) loadFile(path, rel string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(scanner.Text(), rel); ok {
+			m.rules[rel] = append(m.rules[rel], rule)
+		}
+	}
+}
+
+// ignored reports whether rel (slash-separated, relative to the repository root) is excluded.
+// Rules from deeper directories are checked last so they take precedence, and within
+// a file the last matching line wins, as in git.
+func (m *matcher) ignored(rel string, isDir bool) bool {
+	dirs := []string{"."}
+	if parent := filepath.ToSlash(filepath.Dir(rel)); parent != "." {
+		parts := strings.Split(parent, "/")
+		for i := range parts {
+			dirs = append(dirs, strings.Join(parts[:i+1], "/"))
+		}
+	}
+
+	ignored := false
+	for _, dir := range dirs {
+		for _, rule := range m.rules[dir] {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+
+			target := rel
+			if rule.base != "." {
+				target = strings.TrimPrefix(rel, rule.base+"/")
+			}
+			if rule.re.MatchString(target) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// parseIgnoreLine compiles a gitignore pattern defined in directory base
+func parseIgnoreLine(line, base string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to the ignore file's directory
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// globToRegexp translates gitignore glob syntax, including "**", into a regexp
+func globToRegexp(glob string) string {
+	var expr strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					expr.WriteString("(?:.*/)?")
+				} else {
+					expr.WriteString(".*")
+				}
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end <= 1 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				expr.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expr.String()
+}
@@ -0,0 +1,129 @@
+// Package walker walks project trees the way a developer sees them: honoring
+// .gitignore and .ignore files, skipping VCS and dependency directories, and
+// telling text files from binaries.
+package walker
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// SkippedDirs are never descended into unless ignore rules are disabled;
+// .git is skipped even then
+var SkippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// binarySniffLen is how much of a file is inspected for binary content (as git does)
+const binarySniffLen = 8000
+
+// Options controls which entries Walk visits
+type Options struct {
+	// IncludeIgnored disables .gitignore/.ignore handling and SkippedDirs
+	IncludeIgnored bool
+	// IncludeHidden visits dot files and directories other than .git
+	IncludeHidden bool
+}
+
+// WalkFunc is called for every file that is not excluded. rel is the
+// slash-separated path relative to the walk root.
+type WalkFunc func(path, rel string, d fs.DirEntry) error
+
+// Walk visits every non-ignored file under root in lexical order
+func Walk(root string, opts Options, fn WalkFunc) error {
+	// Rules are keyed by paths relative to the repository root, so the
+	// ignore files above a subdirectory apply to it too
+	m := newMatcher()
+	base := "."
+	if !opts.IncludeIgnored {
+		base = m.loadParents(root)
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Continue walking even if there's an error
+		}
+
+		rel, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel == "." {
+			if d.IsDir() && !opts.IncludeIgnored {
+				m.loadDir(path, base)
+			}
+			if !d.IsDir() {
+				return fn(path, filepath.Base(path), d)
+			}
+			return nil
+		}
+
+		name := d.Name()
+		if d.IsDir() && name == ".git" {
+			return filepath.SkipDir
+		}
+		if !opts.IncludeHidden && len(name) > 0 && name[0] == '.' {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !opts.IncludeIgnored {
+			if d.IsDir() && SkippedDirs[name] {
+				return filepath.SkipDir
+			}
+			if m.ignored(joinRel(base, rel), d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
+		if d.IsDir() {
+			if !opts.IncludeIgnored {
+				m.loadDir(path, joinRel(base, rel))
+			}
+			return nil
+		}
+
+		// Skip sockets, devices and other special files
+		if !d.Type().IsRegular() && d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		return fn(path, rel, d)
+	})
+}
+
+// IsBinary reports whether data looks like binary content
+func IsBinary(data []byte) bool {
+	if len(data) > binarySniffLen {
+		data = data[:binarySniffLen]
+	}
+	return bytes.IndexByte(data, 0) != -1
+}
+
+// IsBinaryFile reports whether the file at path looks like binary content
+func IsBinaryFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	buf := make([]byte, binarySniffLen)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false
+	}
+	return IsBinary(buf[:n])
+}